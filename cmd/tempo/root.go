@@ -3,6 +3,7 @@ package tempo
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 
@@ -24,7 +25,6 @@ func Execute(version string) error {
 		Short:   "AI code attribution for git commits",
 		Version: version,
 	}
-
 	rootCmd.AddCommand(
 		newEnableCmd(),
 		newDisableCmd(),
@@ -153,7 +153,7 @@ func newTestCmd() *cobra.Command {
 				return fmt.Errorf("not a git repository")
 			}
 
-			attr, err := detector.Detect(repoRoot)
+			attr, err := detector.Detect(repoRoot, detectOptions(cmd))
 			if err != nil {
 				return err
 			}
@@ -207,9 +207,27 @@ func newTestCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().Bool("json", false, "Output in JSON format")
+	addDetectFlags(cmd)
 	return cmd
 }
 
+// addDetectFlags registers the flags read by detectOptions on a command
+// that runs detection.
+func addDetectFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-cache", false, "Reparse session logs instead of using the session cache, which is on by default")
+}
+
+// detectOptions builds the detection options for cmd from its flags.
+func detectOptions(cmd *cobra.Command) detector.DetectOptions {
+	noCache, _ := cmd.Flags().GetBool("no-cache")
+	return detector.DetectOptions{NoCache: noCache, Version: cliVersion, Warn: printWarning}
+}
+
+// printWarning reports a non-fatal detection problem on stderr.
+func printWarning(msg string) {
+	fmt.Fprintf(os.Stderr, "tempo-cli: warning: %s\n", msg)
+}
+
 func newDetectCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:    "_detect",
@@ -219,7 +237,7 @@ func newDetectCmd() *cobra.Command {
 			if err != nil {
 				return nil
 			}
-			attr, err := detector.Detect(repoRoot)
+			attr, err := detector.Detect(repoRoot, detectOptions(cmd))
 			if err != nil || attr == nil {
 				return nil
 			}
@@ -227,6 +245,7 @@ func newDetectCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().String("hook", "", "hook type (internal)")
+	addDetectFlags(cmd)
 	return cmd
 }
 
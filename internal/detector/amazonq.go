@@ -0,0 +1,211 @@
+package detector
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Amazon Q Developer CLI chat detection.
+//
+// q chat keeps one conversation per working directory in a SQLite database:
+//   macOS:  ~/Library/Application Support/amazon-q/data.sqlite3
+//   Linux:  $XDG_DATA_HOME/amazon-q/data.sqlite3 (default ~/.local/share)
+//   → table conversations(key, value), key = working directory
+//
+// value is the serialized conversation. Its history lists user/assistant
+// exchanges, written as {"user": ..., "assistant": ...} objects by current
+// versions and as [user, assistant] pairs by older ones. File edits are
+// fs_write tool uses on the assistant side:
+//   {"ToolUse": {"tool_uses": [{"id": "t1", "name": "fs_write",
+//                               "args": {"command": "create", "path": "src/app.py"}}]}}
+// and their outcome arrives in the next user message's ToolUseResults.
+// An edit counts unless its result has status "Error".
+//
+// Rows carry no timestamps, so the database's modification time stands in
+// for the conversation's age. The request metadata Q stores measures
+// prompts and responses in characters rather than tokens, so token counts
+// are left at zero.
+//
+// As with Cursor, the sqlite3 CLI is used rather than a Go SQLite driver.
+
+type amazonQConversation struct {
+	ConversationID string            `json:"conversation_id"`
+	History        []json.RawMessage `json:"history"`
+	Model          string            `json:"model"`
+}
+
+type amazonQExchange struct {
+	User      amazonQUserMessage      `json:"user"`
+	Assistant amazonQAssistantMessage `json:"assistant"`
+}
+
+type amazonQUserMessage struct {
+	Content struct {
+		ToolUseResults *struct {
+			ToolUseResults []amazonQToolResult `json:"tool_use_results"`
+		} `json:"ToolUseResults"`
+	} `json:"content"`
+}
+
+type amazonQToolResult struct {
+	ToolUseID string `json:"tool_use_id"`
+	Status    string `json:"status"`
+}
+
+type amazonQAssistantMessage struct {
+	ToolUse *struct {
+		ToolUses []amazonQToolUse `json:"tool_uses"`
+	} `json:"ToolUse"`
+}
+
+type amazonQToolUse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Args struct {
+		Path string `json:"path"`
+	} `json:"args"`
+}
+
+// exchanges decodes the conversation history, accepting both the object
+// and the pair form of each entry. Entries that decode as neither are
+// skipped.
+func (c amazonQConversation) exchanges() []amazonQExchange {
+	var result []amazonQExchange
+	for _, raw := range c.History {
+		var ex amazonQExchange
+		if err := json.Unmarshal(raw, &ex); err == nil {
+			result = append(result, ex)
+			continue
+		}
+		var pair []json.RawMessage
+		if err := json.Unmarshal(raw, &pair); err != nil || len(pair) != 2 {
+			continue
+		}
+		if json.Unmarshal(pair[0], &ex.User) != nil || json.Unmarshal(pair[1], &ex.Assistant) != nil {
+			continue
+		}
+		result = append(result, ex)
+	}
+	return result
+}
+
+// amazonQDBPath returns the path to Amazon Q's database for the current OS,
+// or "" when unsupported.
+func amazonQDBPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support", "amazon-q", "data.sqlite3")
+	case "linux":
+		dataDir := os.Getenv("XDG_DATA_HOME")
+		if dataDir == "" {
+			dataDir = filepath.Join(homeDir, ".local", "share")
+		}
+		return filepath.Join(dataDir, "amazon-q", "data.sqlite3")
+	}
+	return ""
+}
+
+// parseAmazonQConversation extracts the files written by fs_write in a
+// conversation held for repoRoot. Paths starting with ~/ are expanded like
+// Q does, and absolute paths outside repoRoot are dropped. Returns nil when
+// nothing was written.
+func parseAmazonQConversation(data []byte, repoRoot string) *SessionInfo {
+	var conv amazonQConversation
+	if err := json.Unmarshal(data, &conv); err != nil {
+		return nil
+	}
+
+	info := &SessionInfo{
+		Tool:         ToolAmazonQ,
+		FilesWritten: make(map[string]struct{}),
+		Model:        conv.Model,
+		SessionID:    conv.ConversationID,
+	}
+	exchanges := conv.exchanges()
+	failed := make(map[string]bool)
+	for _, ex := range exchanges {
+		if r := ex.User.Content.ToolUseResults; r != nil {
+			for _, res := range r.ToolUseResults {
+				failed[res.ToolUseID] = res.Status == "Error"
+			}
+		}
+	}
+
+	for _, ex := range exchanges {
+		if ex.Assistant.ToolUse == nil {
+			continue
+		}
+		for _, use := range ex.Assistant.ToolUse.ToolUses {
+			if use.Name != "fs_write" || failed[use.ID] {
+				continue
+			}
+			p := strings.TrimSpace(use.Args.Path)
+			if strings.HasPrefix(p, "~/") {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					continue
+				}
+				p = filepath.Join(home, p[2:])
+			}
+			if filepath.IsAbs(p) {
+				rel := strings.TrimPrefix(p, repoRoot+"/")
+				if rel == p {
+					continue
+				}
+				p = rel
+			}
+			if p != "" {
+				info.FilesWritten[p] = struct{}{}
+			}
+		}
+	}
+	if len(info.FilesWritten) == 0 {
+		return nil
+	}
+	return info
+}
+
+// detectAmazonQ finds the Amazon Q conversation for repoRoot and returns the
+// files it wrote, provided the database was modified within maxAge.
+func detectAmazonQ(repoRoot string, maxAge time.Duration) (*SessionInfo, error) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return nil, nil
+	}
+	dbPath := amazonQDBPath()
+	if dbPath == "" {
+		return nil, nil
+	}
+	st, err := os.Stat(dbPath)
+	if err != nil || st.ModTime().Before(time.Now().Add(-maxAge)) {
+		return nil, nil
+	}
+
+	rows, err := sqliteQuery(dbPath, `SELECT key, value FROM conversations`)
+	if err != nil {
+		return nil, nil
+	}
+	for _, row := range rows {
+		var key, value string
+		if json.Unmarshal(row["key"], &key) != nil || key != repoRoot {
+			continue
+		}
+		if json.Unmarshal(row["value"], &value) != nil {
+			continue
+		}
+		if info := parseAmazonQConversation([]byte(value), repoRoot); info != nil {
+			info.EndedAt = st.ModTime()
+			return info, nil
+		}
+	}
+	return nil, nil
+}
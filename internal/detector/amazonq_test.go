@@ -0,0 +1,118 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAmazonQConversation = `{
+  "conversation_id": "c0ffee",
+  "model": "claude-sonnet-4",
+  "history": [
+    {
+      "user": {"content": {"Prompt": {"prompt": "add a handler"}}},
+      "assistant": {"ToolUse": {"message_id": "m1", "content": "", "tool_uses": [
+        {"id": "t1", "name": "fs_write", "args": {"command": "create", "path": "src/handler.py", "file_text": "x"}},
+        {"id": "t2", "name": "fs_write", "args": {"command": "str_replace", "path": "/Users/jose/myproject/src/app.py"}},
+        {"id": "t3", "name": "fs_read", "args": {"path": "README.md"}},
+        {"id": "t4", "name": "fs_write", "args": {"command": "create", "path": "/tmp/scratch.py"}},
+        {"id": "t5", "name": "fs_write", "args": {"command": "append", "path": "src/broken.py"}}
+      ]}}
+    },
+    {
+      "user": {"content": {"ToolUseResults": {"tool_use_results": [
+        {"tool_use_id": "t1", "status": "Success"},
+        {"tool_use_id": "t2", "status": "Success"},
+        {"tool_use_id": "t5", "status": "Error"}
+      ]}}},
+      "assistant": {"Response": {"message_id": "m2", "content": "done"}}
+    }
+  ]
+}`
+
+func TestParseAmazonQConversation(t *testing.T) {
+	info := parseAmazonQConversation([]byte(testAmazonQConversation), testRepoRoot)
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if info.Tool != ToolAmazonQ {
+		t.Errorf("tool: got %q", info.Tool)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"src/app.py", "src/handler.py"}) {
+		t.Errorf("files: got %v", got)
+	}
+	if info.Model != "claude-sonnet-4" || info.SessionID != "c0ffee" {
+		t.Errorf("model/session: got %q/%q", info.Model, info.SessionID)
+	}
+	if info.TotalTokens != 0 {
+		t.Errorf("tokens: got %d, want 0", info.TotalTokens)
+	}
+}
+
+func TestParseAmazonQConversation_PairHistory(t *testing.T) {
+	conv := `{"history": [[
+	  {"content": {"Prompt": {"prompt": "hi"}}},
+	  {"ToolUse": {"tool_uses": [{"id": "t1", "name": "fs_write", "args": {"path": "main.go"}}]}}
+	]]}`
+	info := parseAmazonQConversation([]byte(conv), testRepoRoot)
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"main.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+}
+
+func TestDetectAmazonQ(t *testing.T) {
+	skipIfNoSQLite(t)
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("XDG_DATA_HOME", "")
+
+	dbPath := amazonQDBPath()
+	if dbPath == "" {
+		t.Skip("Amazon Q storage location unknown on this OS")
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	other := strings.ReplaceAll(testAmazonQConversation, "src/handler.py", "src/other.py")
+	createTestDB(t, dbPath, []string{
+		`CREATE TABLE conversations (key TEXT PRIMARY KEY, value TEXT NOT NULL)`,
+		`INSERT INTO conversations VALUES ('/Users/jose/other', '` + other + `')`,
+		`INSERT INTO conversations VALUES ('` + testRepoRoot + `', '` + testAmazonQConversation + `')`,
+	})
+
+	info, err := detectAmazonQ(testRepoRoot, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"src/app.py", "src/handler.py"}) {
+		t.Errorf("files: got %v", got)
+	}
+
+	// A database untouched within maxAge is ignored
+	old := time.Now().Add(-5 * 24 * time.Hour)
+	if err := os.Chtimes(dbPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := detectAmazonQ(testRepoRoot, 72*time.Hour); info != nil {
+		t.Errorf("expected nil for a stale database, got %+v", info)
+	}
+}
+
+func TestDetectAmazonQ_NoDatabase(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	info, err := detectAmazonQ(testRepoRoot, 72*time.Hour)
+	if err != nil || info != nil {
+		t.Errorf("expected nil, nil without Amazon Q, got %+v, %v", info, err)
+	}
+}
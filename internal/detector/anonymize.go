@@ -0,0 +1,79 @@
+package detector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"strings"
+)
+
+// anonymizeHashLen is the number of hex characters kept per hashed component.
+const anonymizeHashLen = 8
+
+// anonymizePath replaces each component of p with a stable hash, keeping the
+// file extension and the directory depth: src/auth.go → 1d8f6a52/9c0e4b7a.go.
+// The same component always hashes to the same value, so shared directories
+// stay recognizable across files.
+func anonymizePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+		ext := ""
+		if i == len(parts)-1 {
+			ext = path.Ext(part)
+			if ext == part {
+				// Dotfiles like .env have no separate extension
+				ext = ""
+			}
+		}
+		sum := sha256.Sum256([]byte(strings.TrimSuffix(part, ext)))
+		parts[i] = hex.EncodeToString(sum[:])[:anonymizeHashLen] + ext
+	}
+	return strings.Join(parts, "/")
+}
+
+// anonymizePaths rewrites every path recorded on the session with
+// anonymizePath.
+func (s *SessionInfo) anonymizePaths() {
+	s.FilesWritten = anonymizeSet(s.FilesWritten)
+	s.DirsWritten = anonymizeSet(s.DirsWritten)
+	s.FilesRead = anonymizeSet(s.FilesRead)
+	s.FilesRenamedFrom = anonymizeSet(s.FilesRenamedFrom)
+	if s.FileActions != nil {
+		actions := make(map[string]FileAction, len(s.FileActions))
+		for f, a := range s.FileActions {
+			actions[anonymizePath(f)] = a
+		}
+		s.FileActions = actions
+	}
+	if s.FileSources != nil {
+		sources := make(map[string][]string, len(s.FileSources))
+		for f, srcs := range s.FileSources {
+			sources[anonymizePath(f)] = srcs
+		}
+		s.FileSources = sources
+	}
+	for i, e := range s.Events {
+		s.Events[i].Path = anonymizePath(e.Path)
+	}
+	for i, turn := range s.Turns {
+		files := make([]string, len(turn.FilesWritten))
+		for j, f := range turn.FilesWritten {
+			files[j] = anonymizePath(f)
+		}
+		s.Turns[i].FilesWritten = files
+	}
+}
+
+func anonymizeSet(set map[string]struct{}) map[string]struct{} {
+	if set == nil {
+		return nil
+	}
+	out := make(map[string]struct{}, len(set))
+	for p := range set {
+		out[anonymizePath(p)] = struct{}{}
+	}
+	return out
+}
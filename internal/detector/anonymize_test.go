@@ -0,0 +1,50 @@
+package detector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnonymizePath(t *testing.T) {
+	a := anonymizePath("src/auth.go")
+	parts := strings.Split(a, "/")
+	if len(parts) != 2 {
+		t.Fatalf("depth not preserved: %q", a)
+	}
+	if !strings.HasSuffix(parts[1], ".go") || strings.Contains(a, "auth") || strings.Contains(a, "src") {
+		t.Errorf("got %q, want hashed components with .go extension", a)
+	}
+
+	// Stable across calls, and shared directories hash identically
+	if again := anonymizePath("src/auth.go"); again != a {
+		t.Errorf("not stable: %q vs %q", a, again)
+	}
+	other := anonymizePath("src/user.go")
+	if !strings.HasPrefix(other, parts[0]+"/") || other == a {
+		t.Errorf("src/user.go: got %q, want same directory hash as %q", other, a)
+	}
+
+	if got := anonymizePath("/abs/.env"); !strings.HasPrefix(got, "/") || strings.Contains(got, "env") {
+		t.Errorf("absolute dotfile: got %q", got)
+	}
+}
+
+func TestSessionInfoAnonymizePaths(t *testing.T) {
+	s := &SessionInfo{
+		FilesWritten: toSet([]string{"src/auth.go"}),
+		DirsWritten:  toSet([]string{"vendor"}),
+		Turns:        []Turn{{FilesWritten: []string{"src/auth.go"}, Tokens: 10}},
+	}
+	s.anonymizePaths()
+
+	want := anonymizePath("src/auth.go")
+	if got := sortedKeys(s.FilesWritten); !equal(got, []string{want}) {
+		t.Errorf("files: got %v, want [%s]", got, want)
+	}
+	if got := sortedKeys(s.DirsWritten); !equal(got, []string{anonymizePath("vendor")}) {
+		t.Errorf("dirs: got %v", got)
+	}
+	if got := s.Turns[0].FilesWritten; !equal(got, []string{want}) {
+		t.Errorf("turn files: got %v", got)
+	}
+}
@@ -0,0 +1,21 @@
+package detector
+
+// Attributes returns the session's metrics as a flat attribute map suitable
+// for OpenTelemetry span or metric attributes. Every key is always present
+// so that exported series keep a stable shape:
+//
+//	tool, model, provider  string
+//	tokens, duration_sec   int64
+//	cost                   float64 (estimated USD)
+//	file_count             int
+func (s *SessionInfo) Attributes() map[string]any {
+	return map[string]any{
+		"tool":         string(s.Tool),
+		"model":        s.Model,
+		"provider":     s.ModelProvider(),
+		"tokens":       s.TotalTokens,
+		"cost":         s.EstimatedCost(),
+		"file_count":   len(s.FilesWritten),
+		"duration_sec": s.SessionDurationSec,
+	}
+}
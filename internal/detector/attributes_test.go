@@ -0,0 +1,52 @@
+package detector
+
+import "testing"
+
+func TestAttributes(t *testing.T) {
+	s := &SessionInfo{
+		Tool:               ToolCodex,
+		Model:              "gpt-5-codex",
+		FilesWritten:       map[string]struct{}{"a.go": {}, "b.go": {}},
+		TotalTokens:        3000,
+		InputTokens:        2000,
+		OutputTokens:       1000,
+		SessionDurationSec: 90,
+	}
+
+	attrs := s.Attributes()
+	if len(attrs) != 7 {
+		t.Errorf("got %d attributes, want 7: %v", len(attrs), attrs)
+	}
+	if v, ok := attrs["tool"].(string); !ok || v != "codex" {
+		t.Errorf("tool: got %#v", attrs["tool"])
+	}
+	if v, ok := attrs["model"].(string); !ok || v != "gpt-5-codex" {
+		t.Errorf("model: got %#v", attrs["model"])
+	}
+	if v, ok := attrs["provider"].(string); !ok || v != "openai" {
+		t.Errorf("provider: got %#v", attrs["provider"])
+	}
+	if v, ok := attrs["tokens"].(int64); !ok || v != 3000 {
+		t.Errorf("tokens: got %#v", attrs["tokens"])
+	}
+	// 2000 * 1.25/1M + 1000 * 10/1M
+	if v, ok := attrs["cost"].(float64); !ok || v != 0.0125 {
+		t.Errorf("cost: got %#v", attrs["cost"])
+	}
+	if v, ok := attrs["file_count"].(int); !ok || v != 2 {
+		t.Errorf("file_count: got %#v", attrs["file_count"])
+	}
+	if v, ok := attrs["duration_sec"].(int64); !ok || v != 90 {
+		t.Errorf("duration_sec: got %#v", attrs["duration_sec"])
+	}
+}
+
+func TestAttributes_UnknownModel(t *testing.T) {
+	attrs := (&SessionInfo{Tool: ToolAider}).Attributes()
+	if attrs["model"] != "" || attrs["provider"] != "" {
+		t.Errorf("expected empty model and provider, got %q, %q", attrs["model"], attrs["provider"])
+	}
+	if v, ok := attrs["cost"].(float64); !ok || v != 0 {
+		t.Errorf("cost: got %#v", attrs["cost"])
+	}
+}
@@ -0,0 +1,168 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Parsed-session cache.
+//
+// Rollouts of finished sessions never change, yet every commit would
+// otherwise reparse all of them. With DetectorConfig.CacheSessions set,
+// detectCodex keeps each rollout's parse result in
+// ~/.cache/tempo/sessions.json:
+//   files[path] = {mod_time, size, results[parse key] = SessionInfo}
+//
+// A file is only reparsed when its modification time or size changed. The
+// result depends on the repo root and on the parse options, so each is
+// stored under a parse key built from them; null records a rollout that
+// belonged to another repo or wrote nothing. The cache records
+// sessionCacheSchema and the tempo version that wrote it, and a cache from
+// another schema or version is discarded as a whole.
+
+// sessionCacheSchema is bumped whenever the cached SessionInfo layout or
+// the parsing rules change in a way the tempo version doesn't capture.
+const sessionCacheSchema = 1
+
+// sessionCache is the on-disk cache of parsed rollouts.
+type sessionCache struct {
+	Schema  int                          `json:"schema"`
+	Version string                       `json:"version"`
+	Files   map[string]*sessionCacheFile `json:"files"`
+
+	path  string
+	dirty bool
+}
+
+// sessionCacheFile holds the parse results of one rollout as of ModTime
+// and Size.
+type sessionCacheFile struct {
+	ModTime time.Time               `json:"mod_time"`
+	Size    int64                   `json:"size"`
+	Results map[string]*SessionInfo `json:"results"`
+}
+
+// sessionCachePath returns ~/.cache/tempo/sessions.json, or "" when the
+// home directory is unknown.
+func sessionCachePath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".cache", "tempo", "sessions.json")
+}
+
+// loadSessionCache reads the session cache written by tempo version. A
+// missing, unreadable or outdated cache yields an empty one.
+func loadSessionCache(version string) *sessionCache {
+	c := &sessionCache{
+		Schema:  sessionCacheSchema,
+		Version: version,
+		Files:   make(map[string]*sessionCacheFile),
+		path:    sessionCachePath(),
+	}
+	if c.path == "" {
+		return c
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return c
+	}
+	var disk sessionCache
+	if json.Unmarshal(data, &disk) != nil || disk.Schema != sessionCacheSchema || disk.Version != version || disk.Files == nil {
+		// Replace the stale cache on the next save
+		c.dirty = true
+		return c
+	}
+	c.Files = disk.Files
+	return c
+}
+
+// lookup returns the cached result for the rollout at path under key. ok
+// is false when there is none or the file changed since it was stored.
+func (c *sessionCache) lookup(path string, st os.FileInfo, key string) (session *SessionInfo, ok bool) {
+	f := c.Files[path]
+	if f == nil || !f.ModTime.Equal(st.ModTime()) || f.Size != st.Size() {
+		return nil, false
+	}
+	session, ok = f.Results[key]
+	if session != nil {
+		// Callers mutate what they're given
+		session = session.clone()
+	}
+	return session, ok
+}
+
+// store records session, which may be nil, as the result for the rollout
+// at path under key. Results stored for an older version of the file are
+// dropped.
+func (c *sessionCache) store(path string, st os.FileInfo, key string, session *SessionInfo) {
+	f := c.Files[path]
+	if f == nil || !f.ModTime.Equal(st.ModTime()) || f.Size != st.Size() {
+		f = &sessionCacheFile{
+			ModTime: st.ModTime(),
+			Size:    st.Size(),
+			Results: make(map[string]*SessionInfo),
+		}
+		c.Files[path] = f
+	}
+	if session != nil {
+		session = session.clone()
+		session.Errors = nil
+	}
+	f.Results[key] = session
+	c.dirty = true
+}
+
+// save writes the cache back if it changed, dropping rollouts that no
+// longer exist. The file is replaced atomically so concurrent hooks never
+// read a partial cache.
+func (c *sessionCache) save() error {
+	if !c.dirty || c.path == "" {
+		return nil
+	}
+	for p := range c.Files {
+		if _, err := os.Stat(p); err != nil {
+			delete(c.Files, p)
+		}
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "sessions-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// parseKey identifies the inputs a Codex rollout's parse result depends
+// on besides the file itself: the repo root and the parse options.
+func (c DetectorConfig) parseKey(repoRoot string) string {
+	return fmt.Sprintf("%s|subdirs=%t|max=%d|heredoc=%t|cwd=%t|include=%s|exclude=%s",
+		repoRoot, c.MatchSubdirs, c.MaxSessionBytes, c.HeredocWrites, c.ResolveCwd,
+		strings.Join(c.IncludeExts, ","), strings.Join(c.ExcludeExts, ","))
+}
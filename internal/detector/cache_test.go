@@ -0,0 +1,169 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectCodex_CacheSessions(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rollout := filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl")
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	write := func(content string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(rollout, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(rollout, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	detect := func(cfg DetectorConfig) []string {
+		t.Helper()
+		info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info == nil {
+			return nil
+		}
+		return sortedKeys(info.FilesWritten)
+	}
+	cfg := DetectorConfig{CacheSessions: true, Version: "1.0.0"}
+
+	write(content, mtime)
+	if got := detect(cfg); !equal(got, []string{"a.go"}) {
+		t.Fatalf("first run: got %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(homeDir, ".cache", "tempo", "sessions.json")); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+
+	// Same size and modification time: the cached result is used
+	write(strings.Replace(content, "touch a.go", "touch b.go", 1), mtime)
+	if got := detect(cfg); !equal(got, []string{"a.go"}) {
+		t.Errorf("unchanged file: got %v, want the cached [a.go]", got)
+	}
+	if got := detect(DetectorConfig{}); !equal(got, []string{"b.go"}) {
+		t.Errorf("without the cache: got %v, want [b.go]", got)
+	}
+
+	// Another version discards the cache
+	if got := detect(DetectorConfig{CacheSessions: true, Version: "1.1.0"}); !equal(got, []string{"b.go"}) {
+		t.Errorf("new version: got %v, want [b.go]", got)
+	}
+
+	// A modified file is reparsed
+	write(strings.Replace(content, "touch a.go", "touch c.go", 1), mtime.Add(time.Minute))
+	if got := detect(DetectorConfig{CacheSessions: true, Version: "1.1.0"}); !equal(got, []string{"c.go"}) {
+		t.Errorf("modified file: got %v, want [c.go]", got)
+	}
+
+	// Results are kept per repo root, including rollouts that don't match
+	if got := detect(DetectorConfig{CacheSessions: true, Version: "1.1.0", MatchSubdirs: true}); !equal(got, []string{"c.go"}) {
+		t.Errorf("other options: got %v, want [c.go]", got)
+	}
+	info, err := detectCodex(context.Background(), "/Users/jose/other", 72*time.Hour, DetectorConfig{CacheSessions: true, Version: "1.1.0"})
+	if err != nil || info != nil {
+		t.Errorf("other repo: got %+v, %v", info, err)
+	}
+	cache := loadSessionCache("1.1.0")
+	if n := len(cache.Files[rollout].Results); n != 3 {
+		t.Errorf("cached results: got %d, want 3", n)
+	}
+}
+
+func TestSessionCache_DropsMissingFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.jsonl")
+	gone := filepath.Join(dir, "gone.jsonl")
+	for _, p := range []string{kept, gone} {
+		if err := os.WriteFile(p, []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cache := loadSessionCache("dev")
+	for _, p := range []string{kept, gone} {
+		st, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache.store(p, st, "key", &SessionInfo{Tool: ToolCodex, FilesWritten: map[string]struct{}{"a.go": {}}})
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := loadSessionCache("dev")
+	if _, ok := reloaded.Files[gone]; ok {
+		t.Error("expected the deleted rollout to be dropped")
+	}
+	st, err := os.Stat(kept)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, ok := reloaded.lookup(kept, st, "key")
+	if !ok || session == nil {
+		t.Fatal("expected a cached session")
+	}
+	if got := sortedKeys(session.FilesWritten); !equal(got, []string{"a.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+	if _, ok := reloaded.lookup(kept, st, "other"); ok {
+		t.Error("expected no result under another key")
+	}
+}
+
+func TestDetectCodex_CacheSaveWarning(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A file where the cache directory should be makes saving fail
+	if err := os.WriteFile(filepath.Join(homeDir, ".cache"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []string
+	cfg := DetectorConfig{
+		CacheSessions: true,
+		Version:       "1.0.0",
+		Warn:          func(msg string) { warnings = append(warnings, msg) },
+	}
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || !equal(sortedKeys(info.FilesWritten), []string{"a.go"}) {
+		t.Fatalf("expected detection to succeed without the cache, got %+v", info)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "saving session cache: ") {
+		t.Errorf("warnings: got %q", warnings)
+	}
+}
@@ -88,7 +88,23 @@ func findRecentSessions(sessionDir string, maxAge time.Duration) ([]string, erro
 
 // parseClaudeSession streams a JSONL file and extracts session info.
 // Only Edit and Write tool_use calls are extracted for file paths.
+// Returns nil for sessions that wrote no files in repoRoot.
 func parseClaudeSession(jsonlPath string, repoRoot string) (*SessionInfo, error) {
+	info, err := parseClaudeSessionRaw(jsonlPath, repoRoot)
+	if info == nil {
+		return nil, err
+	}
+	if len(info.FilesWritten) == 0 {
+		return nil, nil
+	}
+	return info, err
+}
+
+// parseClaudeSessionRaw is parseClaudeSession without the write filter: it
+// returns the session even when nothing was written, so planning and
+// review sessions can be counted. The result is nil only when the file
+// can't be opened.
+func parseClaudeSessionRaw(jsonlPath string, repoRoot string) (*SessionInfo, error) {
 	f, err := os.Open(jsonlPath)
 	if err != nil {
 		return nil, err
@@ -147,6 +163,8 @@ func parseClaudeSession(jsonlPath string, repoRoot string) (*SessionInfo, error)
 		u := msg.Message.Usage
 		info.TotalTokens += u.InputTokens + u.OutputTokens +
 			u.CacheCreationInputTokens + u.CacheReadInputTokens
+		info.InputTokens += u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+		info.OutputTokens += u.OutputTokens
 
 		// Extract file paths from Edit/Write tool_use calls
 		for _, c := range msg.Message.Content {
@@ -167,12 +185,10 @@ func parseClaudeSession(jsonlPath string, repoRoot string) (*SessionInfo, error)
 		}
 	}
 
-	if len(info.FilesWritten) == 0 {
-		return nil, nil
-	}
-
 	if !firstTimestamp.IsZero() && !lastTimestamp.IsZero() {
 		info.SessionDurationSec = int64(lastTimestamp.Sub(firstTimestamp).Seconds())
+		info.StartedAt = firstTimestamp
+		info.EndedAt = lastTimestamp
 	}
 
 	return info, scanner.Err()
@@ -244,15 +244,6 @@ func TestFindRecentSessions_EmptyDir(t *testing.T) {
 
 // --- helpers ---
 
-func sortedKeys(m map[string]struct{}) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	return keys
-}
-
 func equal(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
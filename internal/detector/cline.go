@@ -0,0 +1,238 @@
+package detector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Cline / Roo Code task history detection.
+//
+// Both extensions keep one directory per task under VS Code global storage:
+//   macOS:  ~/Library/Application Support/Code/User/globalStorage/{extension}/tasks/{taskId}/
+//   Linux:  ~/.config/Code/User/globalStorage/{extension}/tasks/{taskId}/
+//
+// api_conversation_history.json holds the messages sent to the model. The
+// environment details in user messages include the task's working directory:
+//   # Current Working Directory (/path/to/repo) Files
+//
+// File writes appear either as native tool_use blocks or as XML-style tool
+// calls in assistant text:
+//   <write_to_file><path>src/main.ts</path>...</write_to_file>
+//
+// ui_messages.json records per-request token usage in "api_req_started" entries.
+
+// clineExtensionIDs are the global storage directory names for Cline and Roo Code.
+var clineExtensionIDs = []string{
+	"saoudrizwan.claude-dev",
+	"rooveterinaryinc.roo-cline",
+}
+
+// clineWriteTools are the tool names that write files.
+var clineWriteTools = map[string]bool{
+	"write_to_file":   true,
+	"replace_in_file": true,
+	"apply_diff":      true,
+	"insert_content":  true,
+}
+
+var (
+	clineCWDPattern     = regexp.MustCompile(`# Current Working Directory \(([^)\n]+)\) Files`)
+	clineXMLToolPattern = regexp.MustCompile(`<(write_to_file|replace_in_file|apply_diff|insert_content)>\s*<path>([^<]+)</path>`)
+)
+
+type clineMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"` // string or []clineContent
+}
+
+type clineContent struct {
+	Type  string     `json:"type"`
+	Text  string     `json:"text"`
+	Name  string     `json:"name"`
+	Input clineInput `json:"input"`
+}
+
+type clineInput struct {
+	Path string `json:"path"`
+}
+
+type clineUIMessage struct {
+	TS   int64  `json:"ts"` // epoch ms
+	Say  string `json:"say"`
+	Text string `json:"text"`
+}
+
+type clineAPIRequest struct {
+	TokensIn  int64 `json:"tokensIn"`
+	TokensOut int64 `json:"tokensOut"`
+}
+
+// clineTaskDirs returns the task history directories for Cline and Roo Code,
+// derived from the VS Code workspace storage locations.
+func clineTaskDirs() []string {
+	var dirs []string
+	for _, wsBase := range vscodeBaseDirs() {
+		globalStorage := filepath.Join(filepath.Dir(wsBase), "globalStorage")
+		for _, ext := range clineExtensionIDs {
+			dirs = append(dirs, filepath.Join(globalStorage, ext, "tasks"))
+		}
+	}
+	return dirs
+}
+
+// findClineTasks returns task directories modified within maxAge.
+func findClineTasks(maxAge time.Duration) []string {
+	cutoff := time.Now().Add(-maxAge)
+	var tasks []string
+	for _, dir := range clineTaskDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			histPath := filepath.Join(dir, entry.Name(), "api_conversation_history.json")
+			info, err := os.Stat(histPath)
+			if err != nil || info.ModTime().Before(cutoff) {
+				continue
+			}
+			tasks = append(tasks, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return tasks
+}
+
+// contents decodes a message's content, which is either a plain
+// string or a list of content blocks.
+func (m clineMessage) contents() []clineContent {
+	var blocks []clineContent
+	if err := json.Unmarshal(m.Content, &blocks); err == nil {
+		return blocks
+	}
+	var text string
+	if err := json.Unmarshal(m.Content, &text); err == nil {
+		return []clineContent{{Type: "text", Text: text}}
+	}
+	return nil
+}
+
+// parseClineTask reads a task directory and extracts the files written, if
+// the task's working directory is repoRoot. Returns nil for other repos.
+func parseClineTask(taskDir string, repoRoot string) (*SessionInfo, error) {
+	data, err := os.ReadFile(filepath.Join(taskDir, "api_conversation_history.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []clineMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, nil
+	}
+
+	info := &SessionInfo{
+		Tool:         ToolCline,
+		FilesWritten: make(map[string]struct{}),
+	}
+
+	cwd := ""
+	addFile := func(p string) {
+		p = strings.TrimSpace(p)
+		if filepath.IsAbs(p) {
+			rel := strings.TrimPrefix(p, repoRoot+"/")
+			if rel == p {
+				return
+			}
+			p = rel
+		}
+		if p != "" {
+			info.FilesWritten[p] = struct{}{}
+		}
+	}
+
+	for _, msg := range messages {
+		for _, c := range msg.contents() {
+			switch {
+			case msg.Role == "user" && cwd == "" && c.Type == "text":
+				if m := clineCWDPattern.FindStringSubmatch(c.Text); m != nil {
+					cwd = strings.TrimSpace(m[1])
+				}
+			case msg.Role == "assistant" && c.Type == "tool_use":
+				if clineWriteTools[c.Name] {
+					addFile(c.Input.Path)
+				}
+			case msg.Role == "assistant" && c.Type == "text":
+				for _, m := range clineXMLToolPattern.FindAllStringSubmatch(c.Text, -1) {
+					addFile(m[2])
+				}
+			}
+		}
+	}
+
+	if cwd != repoRoot || len(info.FilesWritten) == 0 {
+		return nil, nil
+	}
+
+	parseClineUIMessages(filepath.Join(taskDir, "ui_messages.json"), info)
+	return info, nil
+}
+
+// parseClineUIMessages fills token usage and session duration from the
+// task's ui_messages.json, if present.
+func parseClineUIMessages(path string, info *SessionInfo) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var messages []clineUIMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return
+	}
+
+	var first, last int64
+	for _, m := range messages {
+		if m.TS > 0 {
+			if first == 0 || m.TS < first {
+				first = m.TS
+			}
+			if m.TS > last {
+				last = m.TS
+			}
+		}
+		if m.Say != "api_req_started" {
+			continue
+		}
+		var req clineAPIRequest
+		if err := json.Unmarshal([]byte(m.Text), &req); err != nil {
+			continue
+		}
+		info.InputTokens += req.TokensIn
+		info.OutputTokens += req.TokensOut
+		info.TotalTokens += req.TokensIn + req.TokensOut
+	}
+
+	if first > 0 && last > 0 {
+		info.SessionDurationSec = (last - first) / 1000
+		info.StartedAt = time.UnixMilli(first)
+		info.EndedAt = time.UnixMilli(last)
+	}
+}
+
+// detectCline finds recent Cline/Roo Code tasks run in repoRoot and merges
+// them. Tasks are independent, so their tokens and durations are summed.
+func detectCline(repoRoot string, maxAge time.Duration) (*SessionInfo, error) {
+	var sessions []*SessionInfo
+	for _, dir := range findClineTasks(maxAge) {
+		task, err := parseClineTask(dir, repoRoot)
+		if err != nil || task == nil {
+			continue
+		}
+		sessions = append(sessions, task)
+	}
+	return mergeSessions(sessions, usageSum), nil
+}
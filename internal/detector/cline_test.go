@@ -0,0 +1,175 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// testClineTasksDir returns the platform-correct Cline tasks dir under the
+// given home directory (mirrors clineTaskDirs for stable VS Code).
+func testClineTasksDir(homeDir, extension string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support", "Code", "User", "globalStorage", extension, "tasks")
+	default:
+		return filepath.Join(homeDir, ".config", "Code", "User", "globalStorage", extension, "tasks")
+	}
+}
+
+const testClineHistory = `[
+  {"role": "user", "content": [
+    {"type": "text", "text": "<task>add a config loader</task>"},
+    {"type": "text", "text": "<environment_details>\n# Current Working Directory (/Users/jose/myproject) Files\nsrc/\n</environment_details>"}
+  ]},
+  {"role": "assistant", "content": [
+    {"type": "text", "text": "Creating the file.\n<write_to_file>\n<path>src/config.ts</path>\n<content>export {}</content>\n</write_to_file>"}
+  ]},
+  {"role": "user", "content": "[write_to_file for 'src/config.ts'] Result: done"},
+  {"role": "assistant", "content": [
+    {"type": "tool_use", "name": "apply_diff", "input": {"path": "src/index.ts"}},
+    {"type": "tool_use", "name": "read_file", "input": {"path": "README.md"}},
+    {"type": "tool_use", "name": "insert_content", "input": {"path": "/Users/jose/myproject/src/util.ts"}},
+    {"type": "tool_use", "name": "write_to_file", "input": {"path": "/Users/jose/other/x.ts"}}
+  ]}
+]`
+
+const testClineUIMessages = `[
+  {"ts": 1770717600000, "type": "say", "say": "task", "text": "add a config loader"},
+  {"ts": 1770717605000, "type": "say", "say": "api_req_started", "text": "{\"tokensIn\":1000,\"tokensOut\":200}"},
+  {"ts": 1770717690000, "type": "say", "say": "api_req_started", "text": "{\"tokensIn\":500,\"tokensOut\":100}"}
+]`
+
+func writeClineTask(t *testing.T, dir, history, ui string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "api_conversation_history.json"), []byte(history), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ui != "" {
+		if err := os.WriteFile(filepath.Join(dir, "ui_messages.json"), []byte(ui), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseClineTask_Basic(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "1770717600000")
+	writeClineTask(t, dir, testClineHistory, testClineUIMessages)
+
+	info, err := parseClineTask(dir, testRepoRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil session info")
+	}
+
+	// read_file and paths outside the repo are excluded
+	wantFiles := []string{"src/config.ts", "src/index.ts", "src/util.ts"}
+	if got := sortedKeys(info.FilesWritten); !equal(got, wantFiles) {
+		t.Errorf("files: got %v, want %v", got, wantFiles)
+	}
+	if info.TotalTokens != 1800 {
+		t.Errorf("tokens: got %d, want 1800", info.TotalTokens)
+	}
+	if info.InputTokens != 1500 || info.OutputTokens != 300 {
+		t.Errorf("token split: got %d/%d, want 1500/300", info.InputTokens, info.OutputTokens)
+	}
+	if info.SessionDurationSec != 90 {
+		t.Errorf("duration: got %d, want 90", info.SessionDurationSec)
+	}
+	if info.Tool != ToolCline {
+		t.Errorf("tool: got %q", info.Tool)
+	}
+}
+
+func TestParseClineTask_OtherRepo(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "1770717600000")
+	writeClineTask(t, dir, testClineHistory, "")
+
+	info, err := parseClineTask(dir, "/Users/jose/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info != nil {
+		t.Errorf("expected nil for task in another repo, got %+v", info)
+	}
+}
+
+func TestDetectCline_Integration(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	writeClineTask(t, filepath.Join(testClineTasksDir(homeDir, "saoudrizwan.claude-dev"), "1"), testClineHistory, "")
+	rooHistory := `[
+  {"role": "user", "content": "<environment_details>\n# Current Working Directory (/Users/jose/myproject) Files\n</environment_details>"},
+  {"role": "assistant", "content": [{"type": "tool_use", "name": "write_to_file", "input": {"path": "docs/roo.md"}}]}
+]`
+	writeClineTask(t, filepath.Join(testClineTasksDir(homeDir, "rooveterinaryinc.roo-cline"), "2"), rooHistory, "")
+
+	info, err := detectCline(testRepoRoot, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	want := []string{"docs/roo.md", "src/config.ts", "src/index.ts", "src/util.ts"}
+	if got := sortedKeys(info.FilesWritten); !equal(got, want) {
+		t.Errorf("files: got %v, want %v", got, want)
+	}
+}
+
+func TestDetectCline_MergesTaskSpans(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	tasksDir := testClineTasksDir(homeDir, "saoudrizwan.claude-dev")
+	writeClineTask(t, filepath.Join(tasksDir, "1"), testClineHistory, testClineUIMessages)
+	laterUI := `[
+  {"ts": 1770721200000, "type": "say", "say": "task", "text": "follow up"},
+  {"ts": 1770721230000, "type": "say", "say": "api_req_started", "text": "{\"tokensIn\":100,\"tokensOut\":20}"}
+]`
+	writeClineTask(t, filepath.Join(tasksDir, "2"), testClineHistory, laterUI)
+
+	info, err := detectCline(testRepoRoot, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if want := time.UnixMilli(1770717600000); !info.StartedAt.Equal(want) {
+		t.Errorf("started: got %v, want %v", info.StartedAt, want)
+	}
+	if want := time.UnixMilli(1770721230000); !info.EndedAt.Equal(want) {
+		t.Errorf("ended: got %v, want %v", info.EndedAt, want)
+	}
+	if info.TotalTokens != 1920 || info.InputTokens != 1600 || info.OutputTokens != 320 {
+		t.Errorf("tokens: got %d (%d/%d), want the sum 1920 (1600/320)", info.TotalTokens, info.InputTokens, info.OutputTokens)
+	}
+	if info.SessionDurationSec != 120 {
+		t.Errorf("duration: got %d, want the sum 120", info.SessionDurationSec)
+	}
+	if info.Tool != ToolCline {
+		t.Errorf("tool: got %q", info.Tool)
+	}
+}
+
+func TestDetectCline_NotInstalled(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	info, err := detectCline(testRepoRoot, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info != nil {
+		t.Errorf("expected nil when Cline isn't installed, got %+v", info)
+	}
+}
@@ -3,10 +3,17 @@ package detector
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,7 +26,11 @@ type codexLine struct {
 }
 
 type codexSessionMeta struct {
-	CWD string `json:"cwd"`
+	ID            string `json:"id"`
+	CWD           string `json:"cwd"`
+	ModelProvider string `json:"model_provider"`
+	Originator    string `json:"originator"`
+	Source        string `json:"source"`
 }
 
 type codexTurnContext struct {
@@ -27,12 +38,37 @@ type codexTurnContext struct {
 }
 
 type codexEventPayload struct {
-	Type string              `json:"type"`
+	Type string               `json:"type"`
 	Info *codexTokenCountInfo `json:"info,omitempty"`
+
+	// Reason is set on turn_aborted events, e.g. "interrupted".
+	Reason string `json:"reason,omitempty"`
+
+	// Some builds put the usage directly on the payload instead of under info.
+	TotalTokenUsage *codexTokenUsage `json:"total_token_usage,omitempty"`
+	LastTokenUsage  *codexTokenUsage `json:"last_token_usage,omitempty"`
+}
+
+// tokenCount returns the token usage of a token_count event, accepting both
+// the nested info shape and the flat payload shape. Returns nil if neither
+// is present.
+func (ep codexEventPayload) tokenCount() *codexTokenCountInfo {
+	if ep.Info != nil {
+		return ep.Info
+	}
+	if ep.TotalTokenUsage == nil {
+		return nil
+	}
+	info := &codexTokenCountInfo{TotalTokenUsage: *ep.TotalTokenUsage}
+	if ep.LastTokenUsage != nil {
+		info.LastTokenUsage = *ep.LastTokenUsage
+	}
+	return info
 }
 
 type codexTokenCountInfo struct {
 	TotalTokenUsage codexTokenUsage `json:"total_token_usage"`
+	LastTokenUsage  codexTokenUsage `json:"last_token_usage"`
 }
 
 type codexTokenUsage struct {
@@ -42,312 +78,2221 @@ type codexTokenUsage struct {
 }
 
 type codexResponseItem struct {
-	Type      string `json:"type"`
-	Name      string `json:"name"`
-	Arguments string `json:"arguments"`
-	Input     string `json:"input"` // raw patch text for custom_tool_call
+	Type      string          `json:"type"`
+	Name      string          `json:"name"`
+	CallID    string          `json:"call_id"`
+	Arguments string          `json:"arguments"`
+	Input     string          `json:"input"`  // raw patch text for custom_tool_call
+	Output    json.RawMessage `json:"output"` // function_call_output result
+}
+
+// codexExecOutput is the JSON-encoded result of an exec_command call.
+type codexExecOutput struct {
+	Metadata *struct {
+		ExitCode        int     `json:"exit_code"`
+		DurationSeconds float64 `json:"duration_seconds"`
+	} `json:"metadata"`
+}
+
+// Newer builds report exec results as plain text instead of JSON.
+var (
+	execExitCodePattern = regexp.MustCompile(`Process exited with code (-?\d+)`)
+	execWallTimePattern = regexp.MustCompile(`Wall time: ([\d.]+) seconds`)
+)
+
+// parseExecOutput extracts the exit code and duration from an exec_command
+// function_call_output. ok is false when the output records neither.
+func parseExecOutput(raw json.RawMessage) (exitCode int, duration time.Duration, ok bool) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		text = string(raw)
+	}
+
+	var out codexExecOutput
+	if err := json.Unmarshal([]byte(text), &out); err == nil && out.Metadata != nil {
+		d := time.Duration(out.Metadata.DurationSeconds * float64(time.Second))
+		return out.Metadata.ExitCode, d, true
+	}
+
+	if m := execWallTimePattern.FindStringSubmatch(text); m != nil {
+		if secs, err := strconv.ParseFloat(m[1], 64); err == nil {
+			duration = time.Duration(secs * float64(time.Second))
+			ok = true
+		}
+	}
+	if m := execExitCodePattern.FindStringSubmatch(text); m != nil {
+		if code, err := strconv.Atoi(m[1]); err == nil {
+			exitCode = code
+			ok = true
+		}
+	}
+	return exitCode, duration, ok
 }
 
 type codexExecArgs struct {
-	Cmd string `json:"cmd"`
+	Cmd     string `json:"cmd"`
+	Workdir string `json:"workdir"` // directory the command ran in, when not the session cwd
 }
 
 // applyPatchFilePattern extracts file paths from apply_patch input text.
-// Matches lines like: *** Update File: src/main.go
-var applyPatchFilePattern = regexp.MustCompile(`\*\*\* Update File: (.+)`)
+// Matches lines like: *** Update File: src/main.go, and the rename directive
+// that may follow them: *** Move to: src/app.go
+var applyPatchFilePattern = regexp.MustCompile(`\*\*\* (Update File|Move to): (.+)`)
 
 // extractFilesFromPatch parses an apply_patch input string and returns
-// file paths referenced by "*** Update File:" lines.
-func extractFilesFromPatch(input string) []string {
+// file paths referenced by "*** Update File:" lines. An update followed by
+// "*** Move to:" renames the file: the new path is returned in its place and
+// the old one in renamedFrom. Paths are normalized with path.Clean, so
+// ./a.go and a.go are the same file.
+func extractFilesFromPatch(input string) (files, renamedFrom []string) {
 	matches := applyPatchFilePattern.FindAllStringSubmatch(input, -1)
-	var files []string
 	seen := make(map[string]bool)
-	for _, m := range matches {
-		if len(m) < 2 {
-			continue
+	add := func(list []string, p string) []string {
+		if p != "" {
+			p = path.Clean(toSlash(p))
 		}
-		p := strings.TrimSpace(m[1])
-		if p != "" && !seen[p] {
+		if p != "" && p != "." && !seen[p] {
 			seen[p] = true
-			files = append(files, p)
+			list = append(list, p)
 		}
+		return list
 	}
-	return files
+	for i, m := range matches {
+		p := strings.TrimSpace(m[2])
+		if m[1] == "Move to" {
+			continue
+		}
+		if i+1 < len(matches) && matches[i+1][1] == "Move to" {
+			renamedFrom = add(renamedFrom, p)
+			p = strings.TrimSpace(matches[i+1][2])
+		}
+		files = add(files, p)
+	}
+	return files, renamedFrom
+}
+
+// countPatchLines counts the lines added and removed by the hunks of an
+// apply_patch input. Only lines after an "@@" hunk header are counted, and
+// "+++"/"---" file headers are excluded.
+func countPatchLines(input string) (added, removed int) {
+	inHunk := false
+	for _, line := range strings.Split(input, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case strings.HasPrefix(line, "*** "):
+			inHunk = false
+		case !inHunk:
+		case strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
+			added++
+		case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
+			removed++
+		}
+	}
+	return added, removed
 }
 
+// patternKind tells matchFilesInCmd how to read the path capture of a
+// fileWritePatterns entry.
+type patternKind int
+
+const (
+	patternSingle  patternKind = iota // one path
+	patternMulti                      // space-separated paths, flags dropped
+	patternCopy                       // cp/mv operands; see copyDestinations
+	patternDD                         // dd operands; the of= path
+	patternInstall                    // install operands; see installDestinations
+	patternLink                       // ln operands; see linkDestinations
+	patternPerl                       // perl switches, then files; see perlInPlace
+	patternAwk                        // an awk program; see awkProgramWrites
+	patternRsync                      // rsync arguments; see rsyncDestination
+)
+
 // Regex patterns for extracting file paths from shell commands.
-var fileWritePatterns = []*regexp.Regexp{
-	// cat > PATH <<  or  cat > PATH (heredoc/redirect)
-	regexp.MustCompile(`cat\s+>\s+(\S+)`),
-	// tee PATH
-	regexp.MustCompile(`\btee\s+(?:-a\s+)?(\S+)`),
+var fileWritePatterns = []struct {
+	re   *regexp.Regexp
+	kind patternKind
+}{
+	// cat > PATH <<DELIM  or  cat <<DELIM > PATH  or  cat <<DELIM >> PATH
+	// or  cat SRC > PATH (heredoc/redirect, in either order); >| and >>|
+	// override noclobber
+	{regexp.MustCompile(`\bcat\s+(?:[^\s>|;&]+\s+)*>>?\|?\s*([^\s<>|;&]+)`), patternSingle},
+	// tee [-a] PATH [PATH...]
+	{regexp.MustCompile(`\btee\s+([^|;&<>\n]+)`), patternMulti},
 	// touch PATH [PATH...]
-	regexp.MustCompile(`\btouch\s+(.+)`),
-	// cp SOURCE DEST
-	regexp.MustCompile(`\bcp\s+(?:-\w+\s+)*\S+\s+(\S+)`),
-	// mv SOURCE DEST
-	regexp.MustCompile(`\bmv\s+(?:-\w+\s+)*\S+\s+(\S+)`),
-	// sed -i ... PATH (last arg)
-	regexp.MustCompile(`\bsed\s+-i[^\s]*\s+(?:'[^']*'|"[^"]*"|\S+)\s+(\S+)`),
+	{regexp.MustCompile(`\btouch\s+([^|;&<>\n]+)`), patternMulti},
+	// cp [FLAGS] SOURCE... DEST
+	{regexp.MustCompile(`\bcp\s+([^|;&<>\n]+)`), patternCopy},
+	// mv [FLAGS] SOURCE... DEST
+	{regexp.MustCompile(`\bmv\s+([^|;&<>\n]+)`), patternCopy},
+	// sed -i[SUFFIX] [''] [-e] SCRIPT PATH [PATH...]
+	// sed --in-place[=SUFFIX] [-e] SCRIPT PATH [PATH...]
+	{regexp.MustCompile(`\bsed\s+(?:-i[^\s]*|--in-place(?:=\S*)?)\s+(?:''\s+|""\s+)?(?:-e\s+)?(?:'[^']*'|"[^"]*"|\S+)\s+([^|;&<>\n]+)`), patternMulti},
+	// perl -i[SUFFIX] [-p|-n] -e SCRIPT PATH [PATH...], switches in any
+	// order and possibly combined, as in perl -pi -e
+	{perlPattern, patternPerl},
+	// awk PROGRAM [FILE...] > PATH
+	{awkRedirectPattern, patternSingle},
+	// awk '{print > "PATH"}' (redirects inside the program)
+	{awkProgramPattern, patternAwk},
+	// envsubst [SHELL-FORMAT] < TEMPLATE > PATH
+	{regexp.MustCompile(`\benvsubst\b[^|;&>\n]*>>?\|?\s*([^\s<>|;&]+)`), patternSingle},
+	// dd [if=SRC] of=PATH [OPERAND...]
+	{ddPattern, patternDD},
+	// install [FLAGS] SOURCE... DEST
+	{installPattern, patternInstall},
+	// ln [-s] [-f] TARGET... LINK
+	{lnPattern, patternLink},
+	// rsync [FLAGS] SRC... DEST, when DEST is a file
+	{rsyncPattern, patternRsync},
+	// : > PATH  or  > PATH (truncate or create, with no command)
+	{bareRedirectPattern, patternSingle},
+}
+
+// perlPattern matches a perl invocation, capturing its switches, each
+// with an optional quoted argument such as an -e script, and the operands
+// that follow them.
+var perlPattern = regexp.MustCompile(`\bperl\s+((?:-[^\s'"]*\s*(?:'[^']*'|"[^"]*")?\s+)+)([^|;&<>\n]+)`)
+
+// perlInPlaceSwitch matches a -i switch, alone or clustered after other
+// single-letter switches (-pi, -0777i) and with an optional backup suffix.
+var perlInPlaceSwitch = regexp.MustCompile(`(?:^|\s)-[0-9aclnpsw]*i`)
+
+// perlQuotedArg matches a quoted switch argument.
+var perlQuotedArg = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+
+// perlInPlace reports whether perl switches include -i, so the operands
+// are edited in place rather than only read.
+func perlInPlace(switches string) bool {
+	return perlInPlaceSwitch.MatchString(perlQuotedArg.ReplaceAllString(switches, ""))
+}
+
+// awkRedirectPattern matches the shell redirect of an awk invocation.
+// Quoted arguments are skipped whole, so comparisons and redirects inside
+// the program aren't taken for it.
+var awkRedirectPattern = regexp.MustCompile(`\b[gmn]?awk\s+(?:'[^']*'|"[^"]*"|[^'"|;&<>\n])*>>?\|?\s*([^\s<>|;&]+)`)
+
+// awkProgramPattern matches an awk invocation and captures its program,
+// the first single-quoted argument after any options.
+var awkProgramPattern = regexp.MustCompile(`\b[gmn]?awk\s+(?:-\S+\s+(?:(?:"[^"]*"|[^\s'-]\S*)\s+)?)*'([^']*)'`)
+
+// awkPrintRedirect matches a print or printf statement redirected to a
+// string literal that ends the statement. Computed targets such as
+// "out_" $1 are skipped, since the file name isn't known.
+var awkPrintRedirect = regexp.MustCompile(`\bprintf?\b[^;{}>]*>>?\s*"([^"]+)"\s*(?:[;}\n]|$)`)
+
+// awkProgramWrites returns the files an awk program writes with print or
+// printf redirects.
+func awkProgramWrites(program string) []string {
+	var files []string
+	for _, m := range awkPrintRedirect.FindAllStringSubmatch(program, -1) {
+		files = append(files, m[1])
+	}
+	return files
 }
 
+// ddPattern matches dd invocations; the written file is the of= operand.
+var ddPattern = regexp.MustCompile(`\bdd\s+([^|;&<>\n]+)`)
+
+// installPattern matches install(1) only in command position, so package
+// manager subcommands like "npm install a b" aren't taken for it.
+var installPattern = regexp.MustCompile(`(?m)(?:^|[;&|(]|\bsudo)\s*install\s+([^|;&<>\n]+)`)
+
+// lnPattern matches ln invocations; the written file is the link.
+var lnPattern = regexp.MustCompile(`\bln\s+([^|;&<>\n]+)`)
+
+// bareRedirectPattern matches a redirect in command position, i.e. at the
+// start of the command or right after a separator, optionally behind the
+// no-op ":" builtin. Redirects following any other word belong to that
+// command and are left to its own pattern. &> is not a separator.
+var bareRedirectPattern = regexp.MustCompile(`(?m)(?:^|[;(]|&&|\|\|?)\s*(?::\s*)?>>?\|?\s*([^\s<>|;&]+)`)
+
 // extractFilesFromCmd parses a shell command string and returns file paths
 // that were likely written to. Returns paths relative to cwd.
 func extractFilesFromCmd(cmd string) []string {
 	var files []string
+	for _, m := range matchFilesInCmd(cmd) {
+		files = append(files, m.Path)
+	}
+	return files
+}
+
+// fileMatch is a path extracted from a command together with the pattern
+// that matched it.
+type fileMatch struct {
+	Path    string
+	Pattern string
+}
+
+// inlineScriptMatch is the fileMatch pattern of paths written by inline
+// interpreter scripts.
+const inlineScriptMatch = "inline script"
+
+// matchFilesInCmd is extractFilesFromCmd with provenance: each path is
+// returned with the fileWritePatterns regex, or inlineScriptMatch, that
+// produced it.
+func matchFilesInCmd(cmd string) []fileMatch {
+	cmd = normalizeCmd(cmd)
+	var matches []fileMatch
 	seen := make(map[string]bool)
+	add := func(word, pattern string) {
+		for _, p := range expandBraces(word) {
+			p = cleanPath(p)
+			if p != "" && !seen[p] {
+				seen[p] = true
+				matches = append(matches, fileMatch{Path: p, Pattern: pattern})
+			}
+		}
+	}
+
+	for _, p := range inlineScriptWrites(cmd) {
+		add(p, inlineScriptMatch)
+	}
 
-	for _, re := range fileWritePatterns {
-		matches := re.FindAllStringSubmatch(cmd, -1)
-		for _, m := range matches {
+	for _, wp := range fileWritePatterns {
+		src := wp.re.String()
+		for _, m := range findOperands(wp.re, cmd) {
 			if len(m) < 2 {
 				continue
 			}
-			// touch can have multiple space-separated paths
-			if strings.Contains(re.String(), `\btouch\s+`) {
-				for _, p := range strings.Fields(m[1]) {
-					p = cleanPath(p)
-					if p != "" && !seen[p] {
-						seen[p] = true
-						files = append(files, p)
-					}
+			switch wp.kind {
+			// touch, tee and sed can have multiple space-separated paths;
+			// flags such as tee -a and command substitutions are dropped
+			// by cleanPath
+			case patternMulti:
+				for _, p := range shellFields(m[1]) {
+					add(p, src)
 				}
-				continue
-			}
-			p := cleanPath(m[1])
-			if p != "" && !seen[p] {
-				seen[p] = true
-				files = append(files, p)
+			// cp and mv may copy several sources into a directory
+			case patternCopy:
+				for _, p := range copyDestinations(m[1]) {
+					add(p, src)
+				}
+			case patternDD:
+				if of, _ := ddOperands(strings.Fields(m[1])); of != "" {
+					add(of, src)
+				}
+			case patternInstall:
+				for _, p := range installDestinations(m[1]) {
+					add(p, src)
+				}
+			case patternLink:
+				for _, p := range linkDestinations(m[1]) {
+					add(p, src)
+				}
+			case patternAwk:
+				for _, p := range awkProgramWrites(m[1]) {
+					add(p, src)
+				}
+			// a directory destination is recorded in DirsWritten instead
+			case patternRsync:
+				if dest, isDir := rsyncDestination(strings.Fields(m[1])); dest != "" && !isDir {
+					add(dest, src)
+				}
+			case patternPerl:
+				if !perlInPlace(m[1]) {
+					continue
+				}
+				for _, p := range strings.Fields(m[2]) {
+					add(p, src)
+				}
+			default:
+				add(m[1], src)
 			}
 		}
 	}
-	return files
+	return matches
 }
 
-// cleanPath removes quotes, heredoc markers, and filters out non-file paths.
-func cleanPath(p string) string {
-	p = strings.TrimSpace(p)
-	p = strings.Trim(p, `"'`)
-	// Skip heredoc markers (<<'EOF', <<EOF)
-	if strings.HasPrefix(p, "<<") {
-		return ""
-	}
-	// Skip stdout/stderr redirects
-	if p == "/dev/null" || p == "/dev/stdout" || p == "/dev/stderr" {
-		return ""
-	}
-	// Skip flags
-	if strings.HasPrefix(p, "-") {
-		return ""
+// expandBraces performs shell brace expansion on an unquoted word, so
+// src/{a,b}.go yields src/a.go and src/b.go. Nested lists are expanded;
+// braces without a comma, such as ${VAR}, and quoted words are literal.
+func expandBraces(word string) []string {
+	if strings.HasPrefix(word, "'") || strings.HasPrefix(word, `"`) {
+		return []string{word}
 	}
-	// Skip empty or directory-only paths
-	if p == "" || strings.HasSuffix(p, "/") {
-		return ""
+	for open := strings.IndexByte(word, '{'); open >= 0; {
+		depth, end := 0, -1
+		var commas []int
+		for i := open; i < len(word) && end < 0; i++ {
+			switch word[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					end = i
+				}
+			case ',':
+				if depth == 1 {
+					commas = append(commas, i)
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+		if len(commas) == 0 {
+			next := strings.IndexByte(word[open+1:], '{')
+			if next < 0 {
+				break
+			}
+			open += 1 + next
+			continue
+		}
+		prefix, suffix := word[:open], word[end+1:]
+		var words []string
+		start := open + 1
+		for _, sep := range append(commas, end) {
+			words = append(words, expandBraces(prefix+word[start:sep]+suffix)...)
+			start = sep + 1
+		}
+		return words
 	}
-	return p
+	return []string{word}
 }
 
-// findCodexSessions finds all Codex session files for a given repo root.
-// Sessions are stored at ~/.codex/sessions/YYYY/MM/DD/rollout-*.jsonl.
-// Only returns sessions modified within maxAge whose cwd matches the repo root.
-func findCodexSessions(repoRoot string, maxAge time.Duration) ([]string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, nil
-	}
+// inlineScriptPattern matches the start of an inline python -c or node -e script.
+var inlineScriptPattern = regexp.MustCompile(`\b(?:python[0-9.]*\s+-c|node\s+-e)\s+`)
 
-	sessionsDir := filepath.Join(homeDir, ".codex", "sessions")
-	if _, err := os.Stat(sessionsDir); os.IsNotExist(err) {
-		return nil, nil
+// inlineWritePatterns capture paths written by common file APIs inside
+// inline scripts. Python open() is only matched in write or append mode.
+var inlineWritePatterns = []*regexp.Regexp{
+	// open('PATH', 'w')  open("PATH", "ab")
+	regexp.MustCompile(`\bopen\(\s*['"]([^'"]+)['"]\s*,\s*(?:mode\s*=\s*)?['"][wa]b?\+?['"]`),
+	// Path('PATH').write_text(...)  Path("PATH").write_bytes(...)
+	regexp.MustCompile(`\bPath\(\s*['"]([^'"]+)['"]\s*\)\.write_(?:text|bytes)\(`),
+	// fs.writeFileSync('PATH', ...)
+	regexp.MustCompile(`\bwriteFileSync\(\s*['"\x60]([^'"\x60]+)['"\x60]`),
+}
+
+// inlineScriptWrites returns paths written by python -c / node -e scripts
+// embedded in cmd. Only the text following the interpreter flag is scanned.
+func inlineScriptWrites(cmd string) []string {
+	loc := inlineScriptPattern.FindStringIndex(cmd)
+	if loc == nil {
+		return nil
+	}
+	script := cmd[loc[1]:]
+	var paths []string
+	for _, re := range inlineWritePatterns {
+		for _, m := range re.FindAllStringSubmatch(script, -1) {
+			paths = append(paths, m[1])
+		}
 	}
+	return paths
+}
 
-	cutoff := time.Now().Add(-maxAge)
-	pattern := filepath.Join(sessionsDir, "*", "*", "*", "rollout-*.jsonl")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, nil
+// ddOperands returns the of= target of a dd invocation and whether its
+// conv= operand includes notrunc.
+func ddOperands(args []string) (of string, notrunc bool) {
+	for _, a := range args {
+		switch {
+		case strings.HasPrefix(a, "of="):
+			of = strings.TrimPrefix(a, "of=")
+		case strings.HasPrefix(a, "conv="):
+			for _, c := range strings.Split(strings.TrimPrefix(a, "conv="), ",") {
+				if c == "notrunc" {
+					notrunc = true
+				}
+			}
+		}
 	}
+	return of, notrunc
+}
 
-	var sessions []string
-	for _, path := range matches {
-		info, err := os.Stat(path)
-		if err != nil || info.ModTime().Before(cutoff) {
+// extractFileActionsFromCmd tags the files a command writes with how they
+// were changed, for commands whose semantics say so. Currently only dd is
+// tagged: without conv=notrunc it truncates an existing file (overwrite),
+// with it the file is rewritten in place (modify). exists reports whether a
+// path was present; files it reports missing are tagged create.
+func extractFileActionsFromCmd(cmd string, exists func(string) bool) map[string]FileAction {
+	cmd = normalizeCmd(cmd)
+	actions := make(map[string]FileAction)
+	for _, m := range ddPattern.FindAllStringSubmatch(cmd, -1) {
+		of, notrunc := ddOperands(strings.Fields(m[1]))
+		p := cleanPath(of)
+		if p == "" {
 			continue
 		}
-		// Quick check: read first line to verify cwd matches
-		if matchesRepo(path, repoRoot) {
-			sessions = append(sessions, path)
+		switch {
+		case !exists(p):
+			actions[p] = FileActionCreate
+		case notrunc:
+			actions[p] = FileActionModify
+		default:
+			actions[p] = FileActionOverwrite
 		}
 	}
-	return sessions, nil
+	return actions
 }
 
-// matchesRepo reads the first line (session_meta) to check if cwd matches.
-func matchesRepo(jsonlPath string, repoRoot string) bool {
-	f, err := os.Open(jsonlPath)
-	if err != nil {
-		return false
-	}
-	defer f.Close()
+// normalizeCmd prepares a command string for path extraction: bash -c
+// wrappers are unwrapped, heredoc bodies are dropped, backslash line
+// continuations are joined, and unquoted comments are removed.
+func normalizeCmd(cmd string) string {
+	return stripShellComments(stripHeredocBodies(unwrapShellCommands(cmd)))
+}
 
-	scanner := bufio.NewScanner(f)
-	if !scanner.Scan() {
-		return false
-	}
+// shellWrapperPattern matches a bash/sh/zsh -c invocation up to the opening
+// quote of its command string. Combined flags such as -lc are accepted.
+var shellWrapperPattern = regexp.MustCompile(`\b(?:ba|z)?sh\s+-[a-zA-Z]*c[a-zA-Z]*\s+['"]`)
 
-	var line codexLine
-	if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
-		return false
-	}
-	if line.Type != "session_meta" {
-		return false
-	}
+// maxShellUnwrap bounds how many wrappers unwrapShellCommands removes.
+const maxShellUnwrap = 8
 
-	var meta codexSessionMeta
-	if err := json.Unmarshal(line.Payload, &meta); err != nil {
-		return false
+// unwrapShellCommands replaces bash -c "..." wrappers with the command they
+// run, so the inner command is analyzed like any other. Nested wrappers are
+// unwrapped in turn. Unwrapping stops at a wrapper whose quote isn't closed.
+func unwrapShellCommands(cmd string) string {
+	for i := 0; i < maxShellUnwrap; i++ {
+		loc := shellWrapperPattern.FindStringIndex(cmd)
+		if loc == nil {
+			break
+		}
+		start := loc[1] - 1
+		inner, n, ok := unquoteShellWord(cmd[start:])
+		if !ok {
+			break
+		}
+		cmd = cmd[:loc[0]] + inner + cmd[start+n:]
 	}
-	return meta.CWD == repoRoot
+	return cmd
 }
 
-// parseCodexSession streams a Codex JSONL file and extracts session info.
-func parseCodexSession(jsonlPath string) (*SessionInfo, error) {
-	f, err := os.Open(jsonlPath)
-	if err != nil {
-		return nil, err
+// unquoteShellWord reads the quoted shell word at the start of s and
+// returns its value and the number of bytes consumed. Quoted segments
+// joined by an escaped single quote form one word, the usual way to embed a
+// single quote in a single-quoted string. Inside double quotes, backslash
+// escapes \" \\ \$ and \` are resolved. ok is false when a quote isn't
+// closed.
+func unquoteShellWord(s string) (word string, n int, ok bool) {
+	var b strings.Builder
+	i := 0
+	for i < len(s) && (s[i] == '\'' || s[i] == '"') {
+		q := s[i]
+		i++
+		closed := false
+		for i < len(s) {
+			c := s[i]
+			if q == '"' && c == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+				b.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+			i++
+			if c == q {
+				closed = true
+				break
+			}
+			b.WriteByte(c)
+		}
+		if !closed {
+			return "", 0, false
+		}
+		if strings.HasPrefix(s[i:], `\'`) {
+			b.WriteByte('\'')
+			i += 2
+		}
 	}
-	defer f.Close()
+	return b.String(), i, true
+}
 
-	scanner := bufio.NewScanner(f)
-	buf := make([]byte, 0, 1024*1024)
-	scanner.Buffer(buf, 10*1024*1024)
+// heredocPattern matches a heredoc redirection and captures its delimiter:
+// <<EOF, <<'EOF', <<"EOF", <<-EOF.
+var heredocPattern = regexp.MustCompile(`<<(-?)\s*['"]?([A-Za-z_][A-Za-z0-9_]*)['"]?`)
+
+// stripHeredocBodies removes heredoc bodies and their terminator lines from
+// cmd so that text inside a body isn't mistaken for commands, while commands
+// chained after the terminator are still analyzed. Lines ending in a
+// backslash outside a body are joined with the next line.
+func stripHeredocBodies(cmd string) string {
+	stripped, _ := splitHeredocs(cmd)
+	return stripped
+}
 
-	info := &SessionInfo{
-		Tool:         ToolCodex,
-		FilesWritten: make(map[string]struct{}),
+// extractHeredocWrites returns the files written by commands inside cmd's
+// heredoc bodies, such as the redirects of a generated script. They are
+// part of the written content rather than actions the session took, so
+// they are only attributed with DetectorConfig.HeredocWrites set.
+func extractHeredocWrites(cmd string) []string {
+	_, bodies := splitHeredocs(cmd)
+	var files []string
+	for _, body := range bodies {
+		files = append(files, extractFilesFromCmd(body)...)
+		files = append(files, extractHeredocWrites(body)...)
 	}
+	return files
+}
 
-	var firstTimestamp, lastTimestamp time.Time
-	var lastTotalTokens int64
+// splitHeredocs separates cmd into the command text with heredoc bodies
+// removed and the bodies themselves, in order. Each <<DELIM is paired with
+// the next line matching its delimiter after the previous heredoc closes,
+// so chained heredocs that reuse a delimiter such as EOF stay separate.
+func splitHeredocs(cmd string) (string, []string) {
+	if !strings.Contains(cmd, "\n") {
+		return cmd, nil
+	}
 
-	for scanner.Scan() {
-		lineBytes := scanner.Bytes()
+	var out, bodies []string
+	var body []string
+	var pending []string // delimiters whose bodies are still to come, in order
+	var stripTabs []bool
+	cont := ""
+	for _, line := range strings.Split(cmd, "\n") {
+		if len(pending) > 0 {
+			check := line
+			if stripTabs[0] {
+				check = strings.TrimLeft(check, "\t")
+			}
+			if strings.TrimRight(check, " \r") == pending[0] {
+				pending, stripTabs = pending[1:], stripTabs[1:]
+				bodies = append(bodies, strings.Join(body, "\n"))
+				body = nil
+				continue
+			}
+			body = append(body, check)
+			continue
+		}
 
-		var line codexLine
-		if err := json.Unmarshal(lineBytes, &line); err != nil {
+		line = cont + line
+		cont = ""
+		if strings.HasSuffix(line, "\\") {
+			cont = strings.TrimSuffix(line, "\\") + " "
 			continue
 		}
 
-		// Track timestamps for session duration
-		if line.Timestamp != "" {
-			if t, err := time.Parse(time.RFC3339Nano, line.Timestamp); err == nil {
-				if firstTimestamp.IsZero() || t.Before(firstTimestamp) {
-					firstTimestamp = t
-				}
-				if t.After(lastTimestamp) {
-					lastTimestamp = t
-				}
+		for _, loc := range heredocPattern.FindAllStringSubmatchIndex(line, -1) {
+			// Skip here-strings (<<<word)
+			if loc[0] > 0 && line[loc[0]-1] == '<' {
+				continue
 			}
+			stripTabs = append(stripTabs, loc[3] > loc[2])
+			pending = append(pending, line[loc[4]:loc[5]])
 		}
+		out = append(out, line)
+	}
+	if cont != "" {
+		out = append(out, strings.TrimSuffix(cont, " "))
+	}
+	return strings.Join(out, "\n"), bodies
+}
 
-		switch line.Type {
-		case "turn_context":
-			var tc codexTurnContext
-			if err := json.Unmarshal(line.Payload, &tc); err == nil && tc.Model != "" {
-				info.Model = tc.Model
+// stripShellComments removes unquoted # comments from each line of cmd. A #
+// only starts a comment at the beginning of a word, so paths like a#b.go and
+// ${#var} are left intact. Quote state resets at each newline so that
+// apostrophes in heredoc bodies don't swallow the rest of the command.
+func stripShellComments(cmd string) string {
+	if !strings.Contains(cmd, "#") {
+		return cmd
+	}
+	lines := strings.Split(cmd, "\n")
+	for i, line := range lines {
+		var quote byte
+		for j := 0; j < len(line); j++ {
+			c := line[j]
+			switch {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case c == '\'' || c == '"':
+				quote = c
+			case c == '#' && (j == 0 || line[j-1] == ' ' || line[j-1] == '\t'):
+				lines[i] = strings.TrimRight(line[:j], " \t")
+				j = len(line)
 			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
 
-		case "event_msg":
-			// Pre-filter: skip lines without "token_count"
-			if !bytes.Contains(line.Payload, []byte(`"token_count"`)) {
-				continue
-			}
-			var ep codexEventPayload
-			if err := json.Unmarshal(line.Payload, &ep); err != nil {
+// trailingFdPattern matches a file descriptor number ending an operand
+// capture, as the 2 of "cp a.go b.go 2>/dev/null".
+var trailingFdPattern = regexp.MustCompile(`(?:^|\s)\d+$`)
+
+// findOperands is re.FindAllStringSubmatch for the operand captures of
+// fileWritePatterns. Captures stop at redirect operators, so a capture that
+// ends right before < or > would otherwise keep the redirect's fd number
+// (N> or N>&M) as its last operand; that number is dropped.
+func findOperands(re *regexp.Regexp, cmd string) [][]string {
+	var result [][]string
+	for _, loc := range re.FindAllStringSubmatchIndex(cmd, -1) {
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			start, end := loc[2*i], loc[2*i+1]
+			if start < 0 {
 				continue
 			}
-			if ep.Type == "token_count" && ep.Info != nil {
-				lastTotalTokens = ep.Info.TotalTokenUsage.TotalTokens
+			m[i] = cmd[start:end]
+			if i > 0 && end < len(cmd) && (cmd[end] == '>' || cmd[end] == '<') {
+				m[i] = trailingFdPattern.ReplaceAllString(m[i], "")
 			}
+		}
+		result = append(result, m)
+	}
+	return result
+}
 
-		case "response_item":
-			// Pre-filter: skip lines without "exec_command" or "apply_patch"
-			if !bytes.Contains(lineBytes, []byte(`"exec_command"`)) &&
-				!bytes.Contains(lineBytes, []byte(`"apply_patch"`)) {
-				continue
+// shellFields splits s into words like strings.Fields, but keeps quoted
+// strings, backslash-escaped characters, $(...) and backtick substitutions
+// inside a single word, so "my file.go" stays one operand. Quotes and
+// escapes are left on the words for cleanPath to handle.
+func shellFields(s string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote byte
+	depth := 0 // nesting of $( ... )
+	backtick := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
 			}
-			var ri codexResponseItem
-			if err := json.Unmarshal(line.Payload, &ri); err != nil {
-				continue
+		case backtick:
+			if c == '`' {
+				backtick = false
 			}
-			switch ri.Type {
-			case "function_call":
-				if ri.Name == "exec_command" {
-					var args codexExecArgs
-					if err := json.Unmarshal([]byte(ri.Arguments), &args); err != nil {
-						continue
-					}
-					for _, fp := range extractFilesFromCmd(args.Cmd) {
-						info.FilesWritten[fp] = struct{}{}
-					}
-				}
-			case "custom_tool_call":
-				if ri.Name == "apply_patch" {
-					for _, fp := range extractFilesFromPatch(ri.Input) {
-						info.FilesWritten[fp] = struct{}{}
-					}
-				}
+		case c == '\\' && i+1 < len(s):
+			word.WriteByte(c)
+			i++
+			c = s[i]
+		case c == '\'' || c == '"':
+			quote = c
+		case depth > 0:
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
 			}
+		case c == '`':
+			backtick = true
+		case c == '$' && i+1 < len(s) && s[i+1] == '(':
+			word.WriteByte(c)
+			i++
+			c = s[i]
+			depth = 1
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
 		}
+		word.WriteByte(c)
+		inWord = true
 	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
 
-	if len(info.FilesWritten) == 0 {
-		return nil, nil
+// copyDestinations returns the paths written by a cp/mv invocation given its
+// argument string. When the destination is a directory (trailing slash, -t,
+// or several sources), each source basename is placed inside it; otherwise
+// the destination itself is the written file. Quoted operands may contain
+// spaces.
+func copyDestinations(args string) []string {
+	var operands []string
+	targetDir := ""
+	fields := shellFields(args)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case f == "-t" && i+1 < len(fields):
+			targetDir = fields[i+1]
+			i++
+		case strings.HasPrefix(f, "--target-directory="):
+			targetDir = strings.TrimPrefix(f, "--target-directory=")
+		case strings.HasPrefix(f, "-"):
+			continue
+		default:
+			operands = append(operands, f)
+		}
 	}
 
-	info.TotalTokens = lastTotalTokens
+	sources := operands
+	if targetDir == "" {
+		if len(operands) < 2 {
+			return nil
+		}
+		dest := operands[len(operands)-1]
+		sources = operands[:len(operands)-1]
+		if !strings.HasSuffix(strings.Trim(dest, `"'`), "/") && len(sources) == 1 {
+			return []string{dest}
+		}
+		targetDir = dest
+	}
 
-	if !firstTimestamp.IsZero() && !lastTimestamp.IsZero() {
-		info.SessionDurationSec = int64(lastTimestamp.Sub(firstTimestamp).Seconds())
+	dir := strings.TrimSuffix(strings.Trim(targetDir, `"'`), "/")
+	var dests []string
+	for _, src := range sources {
+		base := path.Base(strings.Trim(src, `"'`))
+		if base == "." || base == "/" {
+			continue
+		}
+		dests = append(dests, path.Join(dir, base))
 	}
+	return dests
+}
+
+// installArgFlags are the install(1) flags that take a separate argument.
+var installArgFlags = map[string]bool{"-m": true, "-o": true, "-g": true, "-S": true}
 
-	return info, scanner.Err()
+// installDestinations returns the paths written by an install invocation
+// given its arguments. Flag arguments such as -m 0644 are skipped, and
+// install -d, which only creates directories, writes no files.
+func installDestinations(args string) []string {
+	var kept []string
+	fields := strings.Fields(args)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case f == "-d" || f == "--directory":
+			return nil
+		case installArgFlags[f]:
+			i++
+		default:
+			kept = append(kept, f)
+		}
+	}
+	return copyDestinations(strings.Join(kept, " "))
 }
 
-// detectCodex finds recent Codex sessions for the repo and merges their file sets.
-func detectCodex(repoRoot string, maxAge time.Duration) (*SessionInfo, error) {
-	sessions, err := findCodexSessions(repoRoot, maxAge)
-	if err != nil || len(sessions) == 0 {
-		return nil, nil
+// linkDestinations returns the links created by an ln invocation given its
+// arguments. A lone target links into the current directory under its own
+// name.
+func linkDestinations(args string) []string {
+	var operands []string
+	for _, f := range strings.Fields(args) {
+		if !strings.HasPrefix(f, "-") {
+			operands = append(operands, f)
+		}
+	}
+	if len(operands) == 1 {
+		base := path.Base(strings.Trim(operands[0], `"'`))
+		if base == "." || base == "/" {
+			return nil
+		}
+		return []string{base}
 	}
+	return copyDestinations(args)
+}
 
-	merged := &SessionInfo{
-		Tool:         ToolCodex,
-		FilesWritten: make(map[string]struct{}),
+// Archive extraction commands. Their contents can't be enumerated from the
+// command string, so only the destination directory is recorded.
+var (
+	tarPattern   = regexp.MustCompile(`\btar\s+([^|;&\n]+)`)
+	unzipPattern = regexp.MustCompile(`\bunzip\s+([^|;&\n]+)`)
+	rsyncPattern = regexp.MustCompile(`\brsync\s+([^|;&<>\n]+)`)
+)
+
+// extractDirsFromCmd parses a shell command string and returns directories
+// that were bulk-written by archive extraction (tar -x -C DIR, unzip -d DIR)
+// or by rsync, whose copied files are only known at runtime or listed in a
+// --files-from manifest.
+func extractDirsFromCmd(cmd string) []string {
+	cmd = normalizeCmd(cmd)
+	var dirs []string
+	seen := make(map[string]bool)
+	add := func(d string) {
+		d = cleanDir(d)
+		if d != "" && !seen[d] {
+			seen[d] = true
+			dirs = append(dirs, d)
+		}
 	}
 
-	for _, path := range sessions {
-		session, err := parseCodexSession(path)
-		if err != nil || session == nil {
-			continue
+	for _, m := range tarPattern.FindAllStringSubmatch(cmd, -1) {
+		if d := tarExtractDir(strings.Fields(m[1])); d != "" {
+			add(d)
 		}
-		for f := range session.FilesWritten {
-			merged.FilesWritten[f] = struct{}{}
+	}
+	for _, m := range unzipPattern.FindAllStringSubmatch(cmd, -1) {
+		fields := strings.Fields(m[1])
+		for i, f := range fields {
+			if f == "-d" && i+1 < len(fields) {
+				add(fields[i+1])
+			}
 		}
-		// Use the last session's model and tokens
-		if session.Model != "" {
-			merged.Model = session.Model
+	}
+	for _, m := range rsyncPattern.FindAllStringSubmatch(cmd, -1) {
+		args := strings.Fields(m[1])
+		if dest, manifest := rsyncFilesFrom(args); manifest != "" {
+			add(dest)
+		} else if dest, isDir := rsyncDestination(args); isDir {
+			add(dest)
 		}
-		if session.TotalTokens > merged.TotalTokens {
-			merged.TotalTokens = session.TotalTokens
+	}
+	return dirs
+}
+
+// gitStashPattern matches git stash invocations and captures the
+// subcommand, if any.
+var gitStashPattern = regexp.MustCompile(`\bgit\s+stash\b(?:\s+(push|pop|apply|drop|save|clear|list|show))?`)
+
+// extractGitActions returns the git stash actions in cmd, e.g. "git stash"
+// or "git stash pop". Stashing moves existing changes around rather than
+// making new edits, so these are recorded but never produce written files.
+func extractGitActions(cmd string) []string {
+	cmd = normalizeCmd(cmd)
+	var actions []string
+	for _, m := range gitStashPattern.FindAllStringSubmatch(cmd, -1) {
+		action := "git stash"
+		if m[1] != "" {
+			action += " " + m[1]
 		}
-		if session.SessionDurationSec > merged.SessionDurationSec {
-			merged.SessionDurationSec = session.SessionDurationSec
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// testRunnerPattern matches commands that run a test suite.
+var testRunnerPattern = regexp.MustCompile(`\b(?:go\s+test|pytest|python3?\s+-m\s+pytest|(?:npm|yarn|pnpm)\s+(?:run\s+)?test|cargo\s+test)\b`)
+
+// runsTests reports whether cmd runs a test suite: go test, pytest,
+// npm/yarn/pnpm test or cargo test.
+func runsTests(cmd string) bool {
+	return testRunnerPattern.MatchString(normalizeCmd(cmd))
+}
+
+// envsubstInputPattern captures the template redirected into envsubst.
+var envsubstInputPattern = regexp.MustCompile(`\benvsubst\b[^|;&<>\n]*<\s*([^\s<>|;&]+)`)
+
+// extractReadsFromCmd parses a shell command string and returns files the
+// command read as inputs: rsync --files-from manifests and envsubst templates.
+func extractReadsFromCmd(cmd string) []string {
+	cmd = normalizeCmd(cmd)
+	var files []string
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if p = cleanPath(p); p != "" && !seen[p] {
+			seen[p] = true
+			files = append(files, p)
 		}
 	}
+	for _, m := range rsyncPattern.FindAllStringSubmatch(cmd, -1) {
+		_, manifest := rsyncFilesFrom(strings.Fields(m[1]))
+		add(manifest)
+	}
+	for _, m := range envsubstInputPattern.FindAllStringSubmatch(cmd, -1) {
+		add(m[1])
+	}
+	return files
+}
 
-	if len(merged.FilesWritten) == 0 {
-		return nil, nil
+// rmPattern matches rm only in command position, so subcommands like
+// "npm rm pkg" aren't taken for it.
+var rmPattern = regexp.MustCompile(`(?m)(?:^|[;&|(]|\bsudo)\s*rm\s+([^|;&<>\n]+)`)
+
+// extractDeletesFromCmd parses a shell command string and returns files the
+// command removed with rm.
+func extractDeletesFromCmd(cmd string) []string {
+	cmd = normalizeCmd(cmd)
+	var files []string
+	seen := make(map[string]bool)
+	for _, m := range rmPattern.FindAllStringSubmatch(cmd, -1) {
+		for _, f := range strings.Fields(m[1]) {
+			if p := cleanPath(f); p != "" && !seen[p] {
+				seen[p] = true
+				files = append(files, p)
+			}
+		}
+	}
+	return files
+}
+
+// rsyncFilesFrom returns the local destination and --files-from manifest of
+// an rsync invocation. manifest is "" when the option isn't used; dest is ""
+// for remote (host:path) destinations.
+func rsyncFilesFrom(args []string) (dest, manifest string) {
+	operands, manifest := rsyncOperands(args)
+	if len(operands) >= 2 {
+		dest = operands[len(operands)-1]
+		if strings.Contains(dest, ":") {
+			dest = ""
+		}
+	}
+	return dest, manifest
+}
+
+// rsyncDestination returns the local destination of an rsync copy without
+// --files-from, and whether it is a directory: it ends in a slash, or
+// receives several sources or the contents of one (a source ending in a
+// slash). dest is "" for remote (host:path) destinations and --files-from
+// copies, which rsyncFilesFrom handles.
+func rsyncDestination(args []string) (dest string, isDir bool) {
+	operands, manifest := rsyncOperands(args)
+	if manifest != "" || len(operands) < 2 {
+		return "", false
+	}
+	dest = strings.Trim(operands[len(operands)-1], `"'`)
+	if strings.Contains(dest, ":") {
+		return "", false
+	}
+	sources := operands[:len(operands)-1]
+	isDir = strings.HasSuffix(dest, "/") || len(sources) > 1 ||
+		strings.HasSuffix(strings.Trim(sources[0], `"'`), "/")
+	return dest, isDir
+}
+
+// rsyncArgOptions are the rsync options that take a separate argument.
+var rsyncArgOptions = map[string]bool{
+	"-e": true, "--rsh": true, "-f": true, "--filter": true,
+	"--exclude": true, "--include": true, "--exclude-from": true, "--include-from": true,
+}
+
+// rsyncOperands splits rsync arguments into the source and destination
+// operands and the --files-from manifest, if any. Options and their
+// arguments are dropped.
+func rsyncOperands(args []string) (operands []string, manifest string) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--files-from":
+			if i+1 < len(args) {
+				manifest = args[i+1]
+				i++
+			}
+		case strings.HasPrefix(a, "--files-from="):
+			manifest = strings.TrimPrefix(a, "--files-from=")
+		case rsyncArgOptions[a]:
+			i++
+		case strings.HasPrefix(a, "-"):
+			continue
+		default:
+			operands = append(operands, a)
+		}
+	}
+	return operands, manifest
+}
+
+// tarExtractDir returns the -C/--directory target of a tar extraction, or ""
+// if the command doesn't extract or extracts into the current directory.
+func tarExtractDir(args []string) string {
+	extract := false
+	dir := ""
+	for i, a := range args {
+		switch {
+		case a == "--extract" || a == "--get":
+			extract = true
+		case a == "-C" || a == "--directory":
+			if i+1 < len(args) {
+				dir = args[i+1]
+			}
+		case strings.HasPrefix(a, "--directory="):
+			dir = strings.TrimPrefix(a, "--directory=")
+		case strings.HasPrefix(a, "-C"):
+			dir = strings.TrimPrefix(a, "-C")
+		case strings.HasPrefix(a, "--"):
+			continue
+		case strings.HasPrefix(a, "-") || i == 0:
+			// Short option cluster, e.g. -xzf or the dashless xzf form
+			if strings.Contains(a, "x") {
+				extract = true
+			}
+		}
+	}
+	if !extract {
+		return ""
+	}
+	return dir
+}
+
+// cleanDir normalizes a directory path captured from a command, dropping the
+// trailing slash and anything cleanPath would reject.
+func cleanDir(d string) string {
+	d = strings.Trim(strings.TrimSpace(d), `"'`)
+	d = strings.TrimRight(d, "/")
+	if d == "." || d == "" {
+		return ""
+	}
+	return cleanPath(d)
+}
+
+// cleanPath removes quotes, heredoc markers, and filters out non-file paths.
+// The result is normalized with path.Clean, so ./a.go, a/./b.go and a//b.go
+// dedupe with a.go and a/b.go; a leading / is kept for the escape filter.
+func cleanPath(p string) string {
+	p = strings.TrimSpace(p)
+	p = trimShellSyntax(p)
+	p = strings.Trim(p, `"'`)
+	// Skip heredoc markers (<<'EOF', <<EOF)
+	if strings.HasPrefix(p, "<<") {
+		return ""
+	}
+	// Skip standard streams; /dev/stdin is only ever a source, never a file written
+	if p == "/dev/null" || p == "/dev/stdin" || p == "/dev/stdout" || p == "/dev/stderr" {
+		return ""
+	}
+	// Skip flags
+	if strings.HasPrefix(p, "-") {
+		return ""
+	}
+	// Skip command substitutions; the paths they produce can't be known
+	if strings.Contains(p, "$(") || strings.Contains(p, "`") {
+		return ""
+	}
+	p = toSlash(p)
+	// Skip globs; the files they matched can't be known from the command
+	if strings.Contains(p, "*") {
+		return ""
+	}
+	// Skip empty or directory-only paths
+	if p == "" || strings.HasSuffix(p, "/") {
+		return ""
+	}
+	// Expand ~/ to the home directory. ~user/ can't be resolved for
+	// arbitrary users, so those paths are dropped rather than kept literally.
+	if strings.HasPrefix(p, "~") {
+		if !strings.HasPrefix(p, "~/") {
+			return ""
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		p = filepath.Join(home, strings.TrimPrefix(p, "~/"))
+	}
+	p = path.Clean(p)
+	// The working directory itself, as in "touch ."
+	if p == "." {
+		return ""
+	}
+	return p
+}
+
+// trimShellSyntax strips list and subshell operators captured next to a
+// path, as in "(touch a.go)" or "touch a.go;". Parentheses are only removed
+// when unbalanced, so names like file(1).txt are kept.
+func trimShellSyntax(p string) string {
+	for {
+		trimmed := strings.TrimRight(p, ";,&|")
+		if strings.HasSuffix(trimmed, ")") && strings.Count(trimmed, ")") > strings.Count(trimmed, "(") {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if strings.HasPrefix(trimmed, "(") && strings.Count(trimmed, "(") > strings.Count(trimmed, ")") {
+			trimmed = trimmed[1:]
+		}
+		if trimmed == p {
+			return p
+		}
+		p = trimmed
+	}
+}
+
+// toSlash converts the backslash separators of paths recorded on Windows or
+// under WSL to forward slashes, so src\main.go and src/main.go dedupe. A
+// backslash before a space is a shell escape and is left alone.
+func toSlash(p string) string {
+	if !strings.Contains(p, `\`) {
+		return p
+	}
+	b := []byte(p)
+	for i, c := range b {
+		if c == '\\' && (i+1 == len(b) || b[i+1] != ' ') {
+			b[i] = '/'
+		}
+	}
+	return string(b)
+}
+
+// leadingCdPattern matches a cd at the start of a command that is chained to
+// the rest of it, as in "cd backend && touch app.py".
+var leadingCdPattern = regexp.MustCompile(`^\s*cd\s+(\S+)\s*(?:&&|;)`)
+
+// commandDir returns the directory an exec_command ran in: the session cwd,
+// then the call's workdir, then any leading cd commands. Codex runs every
+// command in a fresh shell, so a cd only affects the command it's part of.
+// A cd whose target can't be resolved statically (cd -, cd ~, $VAR) stops
+// the scan.
+func commandDir(cwd, workdir, cmd string) string {
+	dir := cwd
+	if workdir != "" {
+		dir = joinDir(dir, workdir)
+	}
+	for {
+		m := leadingCdPattern.FindStringSubmatchIndex(cmd)
+		if m == nil {
+			return dir
+		}
+		target := strings.Trim(cmd[m[2]:m[3]], `"'`)
+		if target == "-" || strings.HasPrefix(target, "~") || strings.Contains(target, "$") {
+			return dir
+		}
+		dir = joinDir(dir, target)
+		cmd = cmd[m[1]:]
+	}
+}
+
+func joinDir(dir, p string) string {
+	if path.IsAbs(p) {
+		return path.Clean(p)
+	}
+	return path.Join(dir, p)
+}
+
+// resolvePath resolves p, as written by a command run in dir, to a path
+// relative to the session cwd so that the same file dedupes however it was
+// addressed. Paths outside cwd are kept absolute.
+func resolvePath(cwd, dir, p string) string {
+	if p == "" {
+		return p
+	}
+	abs := joinDir(dir, p)
+	if cwd == "" {
+		return abs
+	}
+	rel, err := filepath.Rel(cwd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return abs
+	}
+	return filepath.ToSlash(rel)
+}
+
+// findCodexSessions finds recent Codex session files across all repos in
+// the sessions directory of the Codex home. The home is the first existing
+// directory of $CODEX_HOME, $XDG_DATA_HOME/codex and ~/.codex; when none
+// exists ~/.codex is assumed, so nothing is found.
+func findCodexSessions(ctx context.Context, maxAge time.Duration) ([]string, error) {
+	return findCodexSessionsIn(ctx, defaultCodexSessionsDir(), maxAge)
+}
+
+// defaultCodexSessionsDir returns the sessions directory of codexHomeDir,
+// or "" when it is unknown.
+func defaultCodexSessionsDir() string {
+	home := codexHomeDir()
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, "sessions")
+}
+
+// codexHomeDir returns the directory Codex keeps its data in, picked as
+// described for findCodexSessions. Returns "" when the home directory is
+// unknown and neither environment variable names an existing directory.
+func codexHomeDir() string {
+	var candidates []string
+	if dir := os.Getenv("CODEX_HOME"); dir != "" {
+		candidates = append(candidates, dir)
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		candidates = append(candidates, filepath.Join(dir, "codex"))
+	}
+	for _, dir := range candidates {
+		if st, err := os.Stat(dir); err == nil && st.IsDir() {
+			return dir
+		}
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".codex")
+}
+
+// codexRolloutGlobs returns the glob patterns matching rollouts under
+// sessionsDir: the current sessionsDir/YYYY/MM/DD/rollout-*.jsonl layout
+// and the flat sessionsDir/rollout-*.jsonl one of older Codex versions,
+// each also gzip-compressed as rollout-*.jsonl.gz.
+func codexRolloutGlobs(sessionsDir string) []string {
+	var globs []string
+	for _, dir := range []string{sessionsDir, filepath.Join(sessionsDir, "*", "*", "*")} {
+		for _, name := range []string{"rollout-*.jsonl", "rollout-*.jsonl.gz"} {
+			globs = append(globs, filepath.Join(dir, name))
+		}
+	}
+	return globs
+}
+
+// findCodexSessionsIn finds recent Codex session files under sessionsDir,
+// in either layout of codexRolloutGlobs. Only modification time is checked
+// here; the cwd is matched while parsing so each file is read once.
+func findCodexSessionsIn(ctx context.Context, sessionsDir string, maxAge time.Duration) ([]string, error) {
+	if sessionsDir == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(sessionsDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	var matches []string
+	for _, glob := range codexRolloutGlobs(sessionsDir) {
+		m, err := filepath.Glob(glob)
+		if err != nil {
+			return nil, nil
+		}
+		matches = append(matches, m...)
+	}
+
+	var sessions []string
+	seen := make(map[string]bool, len(matches))
+	for _, path := range matches {
+		if err := ctx.Err(); err != nil {
+			return sessions, err
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(cutoff) {
+			continue
+		}
+		sessions = append(sessions, path)
+	}
+	return sessions, nil
+}
+
+// cwdMatches reports whether a session cwd belongs to repoRoot. With
+// matchSubdirs, a cwd inside repoRoot also matches. A repo reached through
+// a symlink matches under either path: when the literal paths differ, both
+// are compared again with symlinks resolved.
+func cwdMatches(cwd, repoRoot string, matchSubdirs bool) bool {
+	match := func(cwd, repoRoot string) bool {
+		if matchSubdirs {
+			return cwdWithin(cwd, repoRoot)
+		}
+		return cwd == repoRoot
+	}
+	if match(cwd, repoRoot) {
+		return true
+	}
+	if cwd == "" {
+		return false
+	}
+	return match(evalSymlinks(cwd), evalSymlinks(repoRoot))
+}
+
+// evalSymlinks returns p with symlinks resolved, or p unchanged when it
+// can't be resolved, e.g. because it no longer exists.
+func evalSymlinks(p string) string {
+	if resolved, err := filepath.EvalSymlinks(p); err == nil {
+		return resolved
+	}
+	return p
+}
+
+// cwdWithin reports whether cwd is repoRoot or one of its descendants. The
+// comparison is on whole path components, so /repo2 is not within /repo.
+func cwdWithin(cwd, repoRoot string) bool {
+	if cwd == "" {
+		return false
+	}
+	root := strings.TrimSuffix(repoRoot, "/")
+	return cwd == root || strings.HasPrefix(cwd, root+"/")
+}
+
+// timestampLayouts are the timestamp formats seen in Codex rollouts, tried
+// in order. Layouts without a zone are read as UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05",
+}
+
+// parseTimestamp parses s with the first matching layout in
+// timestampLayouts.
+func parseTimestamp(s string) (time.Time, bool) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// codexMetaScanLines is how many leading lines are searched for the
+// session_meta record. It is normally first, but exported or converted
+// rollouts may lead with a turn_context or blank line.
+const codexMetaScanLines = 5
+
+// parseCodexSession streams a Codex JSONL file and extracts session info.
+// It returns nil for sessions that wrote no files or directories, the
+// contract detection relies on; see parseCodexSessionRaw for the rest.
+func parseCodexSession(ctx context.Context, jsonlPath string) (*SessionInfo, error) {
+	return parseCodexRollout(ctx, jsonlPath, nil, DetectorConfig{})
+}
+
+// parseCodexSessionRaw is parseCodexSession without the write filter: it
+// returns the session even when nothing was written, so the token usage
+// and duration of planning-only sessions can be reported. The result is
+// nil only on error. Detection must keep using parseCodexSession, since
+// callers there treat a non-nil session as one that wrote files.
+func parseCodexSessionRaw(ctx context.Context, jsonlPath string) (*SessionInfo, error) {
+	return parseCodexRollout(ctx, jsonlPath, nil, DetectorConfig{keepEmpty: true})
+}
+
+// parseCodexReader is parseCodexSession for a rollout read from r, such as
+// one held in memory or streamed over stdin. r must yield uncompressed
+// JSONL.
+func parseCodexReader(r io.Reader) (*SessionInfo, error) {
+	return parseCodexStream(context.Background(), r, nil, DetectorConfig{})
+}
+
+// ParseVerbose parses a Codex rollout like detection does, without the repo
+// filter, and logs to w every command and patch the session ran together
+// with the paths extracted from it and the pattern that matched each one.
+// It is meant for debugging the extraction heuristics.
+func ParseVerbose(jsonlPath string, w io.Writer) (*SessionInfo, error) {
+	return parseCodexRollout(context.Background(), jsonlPath, nil, DetectorConfig{trace: w})
+}
+
+// traceMatches writes a ParseVerbose log entry for one command or patch;
+// input is omitted when empty.
+func traceMatches(w io.Writer, lineNum int, kind, input string, matches []fileMatch) {
+	if input == "" {
+		fmt.Fprintf(w, "line %d: %s\n", lineNum+1, kind)
+	} else {
+		fmt.Fprintf(w, "line %d: %s: %s\n", lineNum+1, kind, input)
+	}
+	if len(matches) == 0 {
+		fmt.Fprintln(w, "  no match")
+	}
+	for _, m := range matches {
+		fmt.Fprintf(w, "  %s <- %s\n", m.Path, m.Pattern)
+	}
+}
+
+// parseCodexSessionFor parses a rollout only if its session_meta cwd
+// belongs to repoRoot, returning nil as soon as it's known not to.
+// cfg.MatchSubdirs, cfg.MaxSessionBytes, cfg.HeredocWrites and the
+// extension filters apply.
+func parseCodexSessionFor(ctx context.Context, jsonlPath string, repoRoot string, cfg DetectorConfig) (*SessionInfo, error) {
+	return parseCodexRollout(ctx, jsonlPath, func(cwd string) bool {
+		return cwdMatches(cwd, repoRoot, cfg.MatchSubdirs)
+	}, cfg)
+}
+
+// parseCodexSessionCached is parseCodexSessionFor backed by cache, which
+// may be nil. Only complete, error-free parses are stored.
+func parseCodexSessionCached(ctx context.Context, path string, repoRoot string, cfg DetectorConfig, cache *sessionCache) (*SessionInfo, error) {
+	if cache == nil {
+		return parseCodexSessionFor(ctx, path, repoRoot, cfg)
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		return parseCodexSessionFor(ctx, path, repoRoot, cfg)
+	}
+	key := cfg.parseKey(repoRoot)
+	if session, ok := cache.lookup(path, st, key); ok {
+		return session, nil
+	}
+	session, err := parseCodexSessionFor(ctx, path, repoRoot, cfg)
+	if err == nil && ctx.Err() == nil {
+		cache.store(path, st, key, session)
+	}
+	return session, err
+}
+
+// codexCtxCheckLines is how often, in lines, parsing checks for
+// cancellation.
+const codexCtxCheckLines = 1000
+
+// codexSampleBytes is how much of the head and of the tail of an oversized
+// rollout is read in sampling mode.
+const codexSampleBytes = 1 << 20
+
+// sampleRollout returns a reader over the first and last codexSampleBytes
+// of a rollout. The head holds session_meta and the first turn_context; the
+// tail holds the latest model and the cumulative token_count. Lines cut at
+// either boundary fail to decode and are skipped by the parser.
+func sampleRollout(f *os.File, size int64) io.Reader {
+	head := io.NewSectionReader(f, 0, codexSampleBytes)
+	tailStart := size - codexSampleBytes
+	if tailStart < codexSampleBytes {
+		tailStart = codexSampleBytes
+	}
+	tail := io.NewSectionReader(f, tailStart, size-tailStart)
+	return io.MultiReader(head, strings.NewReader("\n"), tail)
+}
+
+// parseCodexRollout streams a rollout in a single pass. When accept is set,
+// the session_meta record must appear within the first codexMetaScanLines
+// lines and its cwd be accepted, otherwise parsing stops early and nil is
+// returned. Files larger than cfg.MaxSessionBytes (when positive) are only
+// sampled: model and token totals are recovered, but writes in the skipped
+// middle are missed, and a warning goes to cfg.Warn. Rollouts ending in .gz
+// are decompressed while reading; they can't be sampled, so MaxSessionBytes
+// doesn't apply to them.
+func parseCodexRollout(ctx context.Context, jsonlPath string, accept func(cwd string) bool, cfg DetectorConfig) (*SessionInfo, error) {
+	f, err := os.Open(jsonlPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(jsonlPath, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	} else if cfg.MaxSessionBytes > 0 {
+		if st, err := f.Stat(); err == nil && st.Size() > cfg.MaxSessionBytes {
+			cfg.warnf("%s is %d bytes, sampling its head and tail", jsonlPath, st.Size())
+			r = sampleRollout(f, st.Size())
+		}
+	}
+
+	return parseCodexStream(ctx, r, accept, cfg)
+}
+
+// parseCodexStream parses the rollout lines read from r. accept and cfg
+// are as for parseCodexRollout, except that no sampling is done.
+func parseCodexStream(ctx context.Context, r io.Reader, accept func(cwd string) bool, cfg DetectorConfig) (*SessionInfo, error) {
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 1024*1024)
+	scanner.Buffer(buf, 10*1024*1024)
+
+	p := newCodexRolloutParser(accept, cfg)
+	for lineNum := 0; scanner.Scan(); lineNum++ {
+		if lineNum%codexCtxCheckLines == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if !p.parseLine(scanner.Bytes()) {
+			return nil, nil
+		}
+	}
+	return p.session(), scanner.Err()
+}
+
+// codexRolloutParser accumulates a SessionInfo from rollout lines. Lines
+// may be fed in several batches, so a growing rollout can be parsed
+// incrementally without re-reading it from the top.
+type codexRolloutParser struct {
+	accept   func(cwd string) bool
+	cfg      DetectorConfig
+	info     *SessionInfo
+	cwd      string
+	metaSeen bool
+	lineNum  int
+
+	firstTimestamp, lastTimestamp time.Time
+	lastUsage                     codexTokenUsage
+
+	// Files written since the last token_count event. The token_count that
+	// follows a model response reports that response's usage, so pending
+	// writes are attributed to the next event's last_token_usage.
+	turnFiles []string
+	turnSeen  map[string]bool
+
+	// lineTime is the timestamp of the line being parsed, zero when absent.
+	lineTime time.Time
+
+	// exec_command call IDs awaiting their function_call_output
+	execCalls map[string]bool
+
+	// A record pretty-printed across several lines, collected until it
+	// decodes; see joinRecord.
+	pending      []byte
+	pendingLines int
+	pendingStart int
+}
+
+// codexMaxRecordLines and codexMaxRecordBytes bound how much of a
+// multi-line record joinRecord collects before giving up on it.
+const (
+	codexMaxRecordLines = 1000
+	codexMaxRecordBytes = 10 << 20
+)
+
+// joinRecord handles a line that doesn't decode on its own, as when an
+// export pretty-prints each record across several lines. A line opening
+// an object starts a pending record and later lines are appended to it.
+// Decoding is retried whenever a line starts with a closing brace; on
+// success the record is decoded into line and true is returned, and
+// p.pendingStart holds its first line. Records exceeding the size limits
+// are dropped. Ordinary malformed lines never start a record.
+func (p *codexRolloutParser) joinRecord(lineBytes []byte, lineNum int, line *codexLine) bool {
+	trimmed := bytes.TrimSpace(lineBytes)
+	if p.pending == nil {
+		if !bytes.HasPrefix(trimmed, []byte("{")) {
+			return false
+		}
+		p.pending = []byte{}
+		p.pendingStart = lineNum
+	}
+	p.pending = append(p.pending, lineBytes...)
+	p.pending = append(p.pending, '\n')
+	p.pendingLines++
+
+	if bytes.HasPrefix(trimmed, []byte("}")) {
+		var rec codexLine
+		if err := json.Unmarshal(p.pending, &rec); err == nil {
+			*line = rec
+			return true
+		}
+	}
+	if p.pendingLines >= codexMaxRecordLines || len(p.pending) > codexMaxRecordBytes {
+		p.resetPending()
+	}
+	return false
+}
+
+// resetPending discards any partially collected multi-line record.
+func (p *codexRolloutParser) resetPending() {
+	p.pending, p.pendingLines = nil, 0
+}
+
+// codexTaskEvents are the event_msg types that start or end a task.
+var codexTaskEvents = [][]byte{
+	[]byte(`"task_started"`),
+	[]byte(`"task_complete"`),
+	[]byte(`"turn_aborted"`),
+	[]byte(`"error"`),
+}
+
+// hasCodexTaskEvent reports whether an event_msg payload may be one of
+// codexTaskEvents.
+func hasCodexTaskEvent(payload []byte) bool {
+	for _, ev := range codexTaskEvents {
+		if bytes.Contains(payload, ev) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordTaskEnd updates EndReason and Interrupted from a task lifecycle
+// event, so they describe how the latest task ended. A task still running
+// when the rollout ends leaves both unset: the session may simply be live.
+func (p *codexRolloutParser) recordTaskEnd(ep codexEventPayload) {
+	info := p.info
+	switch ep.Type {
+	case "task_started":
+		info.EndReason, info.Interrupted = "", false
+	case "task_complete":
+		info.EndReason, info.Interrupted = "completed", false
+	case "turn_aborted":
+		info.EndReason, info.Interrupted = ep.Reason, true
+		if info.EndReason == "" {
+			info.EndReason = "aborted"
+		}
+	case "error":
+		info.EndReason, info.Interrupted = "error", true
+	}
+}
+
+// newCodexRolloutParser returns a parser for one rollout. When accept is
+// set, the session_meta record must appear within the first
+// codexMetaScanLines lines and its cwd be accepted.
+func newCodexRolloutParser(accept func(cwd string) bool, cfg DetectorConfig) *codexRolloutParser {
+	return &codexRolloutParser{
+		accept: accept,
+		cfg:    cfg,
+		info: &SessionInfo{
+			Tool:             ToolCodex,
+			FilesWritten:     make(map[string]struct{}),
+			DirsWritten:      make(map[string]struct{}),
+			FilesRead:        make(map[string]struct{}),
+			FileActions:      make(map[string]FileAction),
+			FilesRenamedFrom: make(map[string]struct{}),
+		},
+		metaSeen:  accept == nil,
+		turnSeen:  make(map[string]bool),
+		execCalls: make(map[string]bool),
+	}
+}
+
+// exists checks a command's target against the session's working
+// directory. Sessions are parsed after the fact, so a file the session
+// itself created also exists; files written earlier in the session
+// therefore count as existing too.
+func (p *codexRolloutParser) exists(path string) bool {
+	if _, ok := p.info.FilesWritten[path]; ok {
+		return true
+	}
+	if !filepath.IsAbs(path) {
+		if p.cwd == "" {
+			return false
+		}
+		path = filepath.Join(p.cwd, path)
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// addEvent records a file event at the current line's time. Events
+// repeating the previous one's path and kind are dropped.
+func (p *codexRolloutParser) addEvent(fp string, kind FileEventKind) {
+	info := p.info
+	if n := len(info.Events); n > 0 && info.Events[n-1].Path == fp && info.Events[n-1].Kind == kind {
+		return
+	}
+	info.Events = append(info.Events, FileEvent{Path: fp, Time: p.lineTime, Kind: kind})
+}
+
+func (p *codexRolloutParser) addFile(fp string) {
+	if !p.cfg.extAllowed(fp) {
+		return
+	}
+	kind := FileEventWrite
+	if !p.exists(fp) {
+		kind = FileEventCreate
+	}
+	p.addEvent(fp, kind)
+	p.info.FilesWritten[fp] = struct{}{}
+	if !p.turnSeen[fp] {
+		p.turnSeen[fp] = true
+		p.turnFiles = append(p.turnFiles, fp)
+	}
+}
+
+// parseLine processes one rollout line. It returns false once the rollout
+// is known not to belong to an accepted cwd; the parser must not be used
+// after that.
+func (p *codexRolloutParser) parseLine(lineBytes []byte) bool {
+	lineNum := p.lineNum
+	p.lineNum++
+	if p.pending == nil && !p.metaSeen && lineNum >= codexMetaScanLines {
+		return false
+	}
+
+	var line codexLine
+	if err := json.Unmarshal(lineBytes, &line); err == nil {
+		p.resetPending()
+	} else if !p.joinRecord(lineBytes, lineNum, &line) {
+		return true
+	} else {
+		lineBytes, lineNum = p.pending, p.pendingStart
+		p.resetPending()
+	}
+
+	info, cfg := p.info, p.cfg
+
+	// Track timestamps for session duration
+	p.lineTime = time.Time{}
+	if line.Timestamp != "" {
+		if t, ok := parseTimestamp(line.Timestamp); ok {
+			p.lineTime = t
+			if p.firstTimestamp.IsZero() || t.Before(p.firstTimestamp) {
+				p.firstTimestamp = t
+			}
+			if t.After(p.lastTimestamp) {
+				p.lastTimestamp = t
+			}
+		}
+	}
+
+	switch line.Type {
+	case "session_meta":
+		var meta codexSessionMeta
+		if err := json.Unmarshal(line.Payload, &meta); err == nil {
+			p.cwd = meta.CWD
+			info.SessionID = meta.ID
+			info.Provider = meta.ModelProvider
+			info.Originator = meta.Originator
+			info.Source = meta.Source
+		}
+		if !p.metaSeen {
+			if !p.accept(p.cwd) {
+				return false
+			}
+			p.metaSeen = true
+		}
+		info.CWD = p.cwd
+
+	case "turn_context":
+		var tc codexTurnContext
+		if err := json.Unmarshal(line.Payload, &tc); err == nil && tc.Model != "" {
+			info.Model = tc.Model
+		}
+
+	case "event_msg":
+		// Pre-filter: skip lines other than token counts and task endings
+		if !bytes.Contains(line.Payload, []byte(`"token_count"`)) && !hasCodexTaskEvent(line.Payload) {
+			return true
+		}
+		var ep codexEventPayload
+		if err := json.Unmarshal(line.Payload, &ep); err != nil {
+			return true
+		}
+		p.recordTaskEnd(ep)
+		if tc := ep.tokenCount(); ep.Type == "token_count" && tc != nil {
+			p.lastUsage = tc.TotalTokenUsage
+			if len(p.turnFiles) > 0 {
+				info.Turns = append(info.Turns, Turn{
+					FilesWritten: p.turnFiles,
+					Tokens:       tc.LastTokenUsage.TotalTokens,
+				})
+				p.turnFiles = nil
+				p.turnSeen = make(map[string]bool)
+			}
+		}
+
+	case "response_item":
+		// Pre-filter: skip lines without "exec_command", "apply_patch"
+		// or a command result
+		if !bytes.Contains(lineBytes, []byte(`"exec_command"`)) &&
+			!bytes.Contains(lineBytes, []byte(`"apply_patch"`)) &&
+			!bytes.Contains(lineBytes, []byte(`"function_call_output"`)) {
+			return true
+		}
+		var ri codexResponseItem
+		if err := json.Unmarshal(line.Payload, &ri); err != nil {
+			return true
+		}
+		switch ri.Type {
+		case "function_call":
+			if ri.Name == "exec_command" {
+				if ri.CallID != "" {
+					p.execCalls[ri.CallID] = true
+				}
+				var args codexExecArgs
+				if err := json.Unmarshal([]byte(ri.Arguments), &args); err != nil {
+					return true
+				}
+				cwd := p.cwd
+				resolve := func(path string) string { return path }
+				if cfg.ResolveCwd {
+					dir := commandDir(cwd, args.Workdir, args.Cmd)
+					resolve = func(path string) string { return resolvePath(cwd, dir, path) }
+				}
+				resolvedExists := func(path string) bool { return p.exists(resolve(path)) }
+				for fp, action := range extractFileActionsFromCmd(args.Cmd, resolvedExists) {
+					info.FileActions[resolve(fp)] = action
+				}
+				matches := matchFilesInCmd(args.Cmd)
+				if cfg.trace != nil {
+					traceMatches(cfg.trace, lineNum, "exec_command", args.Cmd, matches)
+				}
+				for _, m := range matches {
+					p.addFile(resolve(m.Path))
+				}
+				if cfg.HeredocWrites {
+					for _, fp := range extractHeredocWrites(args.Cmd) {
+						p.addFile(resolve(fp))
+					}
+				}
+				for _, d := range extractDirsFromCmd(args.Cmd) {
+					info.DirsWritten[resolve(d)] = struct{}{}
+				}
+				for _, fp := range extractDeletesFromCmd(args.Cmd) {
+					p.addEvent(resolve(fp), FileEventDelete)
+				}
+				for _, fp := range extractReadsFromCmd(args.Cmd) {
+					info.FilesRead[resolve(fp)] = struct{}{}
+				}
+				info.GitActions = append(info.GitActions, extractGitActions(args.Cmd)...)
+				if runsTests(args.Cmd) {
+					info.RanTests = true
+				}
+			}
+		case "function_call_output":
+			if !p.execCalls[ri.CallID] {
+				return true
+			}
+			delete(p.execCalls, ri.CallID)
+			if exitCode, d, ok := parseExecOutput(ri.Output); ok {
+				if exitCode != 0 {
+					info.FailedCommands++
+				}
+				info.ExecDuration += d
+			}
+		case "custom_tool_call":
+			if ri.Name == "apply_patch" {
+				files, renamedFrom := extractFilesFromPatch(ri.Input)
+				if cfg.trace != nil {
+					var matches []fileMatch
+					for _, fp := range files {
+						matches = append(matches, fileMatch{Path: fp, Pattern: applyPatchFilePattern.String()})
+					}
+					traceMatches(cfg.trace, lineNum, "apply_patch", "", matches)
+				}
+				for _, fp := range renamedFrom {
+					info.FilesRenamedFrom[fp] = struct{}{}
+					p.addEvent(fp, FileEventDelete)
+				}
+				for _, fp := range files {
+					p.addFile(fp)
+				}
+				added, removed := countPatchLines(ri.Input)
+				info.LinesAdded += added
+				info.LinesRemoved += removed
+			}
+		}
+	}
+	return true
+}
+
+// session returns the session parsed so far, or nil when no session_meta
+// was accepted or, unless cfg.keepEmpty is set, nothing was written yet.
+// Totals are recomputed on each
+// call, so it may be called again after more lines are parsed; the same
+// SessionInfo is returned each time.
+func (p *codexRolloutParser) session() *SessionInfo {
+	info := p.info
+	if !p.metaSeen || (len(info.FilesWritten) == 0 && len(info.DirsWritten) == 0 && !p.cfg.keepEmpty) {
+		return nil
+	}
+
+	info.NetLineDelta = int64(info.LinesAdded - info.LinesRemoved)
+	info.DirectoriesTouched = countDirs(info.FilesWritten)
+	info.TotalTokens = p.lastUsage.TotalTokens
+	info.InputTokens = p.lastUsage.InputTokens
+	info.OutputTokens = p.lastUsage.OutputTokens
+
+	if !p.firstTimestamp.IsZero() && !p.lastTimestamp.IsZero() {
+		info.SessionDurationSec = int64(p.lastTimestamp.Sub(p.firstTimestamp).Seconds())
+		info.StartedAt = p.firstTimestamp
+		info.EndedAt = p.lastTimestamp
+	}
+	return info
+}
+
+// detectCodex finds recent Codex sessions for the repo and merges their file sets.
+// Files that fail to parse are reported in the merged session's Errors; an
+// error is returned only if every session file failed.
+// With cfg.SameDayOnly set, only sessions from the most recent calendar day
+// are merged. With cfg.CacheSessions set, rollouts unchanged since an
+// earlier run are served from the session cache instead of reparsed.
+func detectCodex(ctx context.Context, repoRoot string, maxAge time.Duration, cfg DetectorConfig) (*SessionInfo, error) {
+	paths, err := findCodexSessionsIn(ctx, cfg.codexSessionsDir(), maxAge)
+	if err != nil {
+		return nil, ctx.Err()
+	}
+
+	var cache *sessionCache
+	if cfg.CacheSessions && cfg.trace == nil {
+		cache = loadSessionCache(cfg.Version)
+		defer func() {
+			if err := cache.save(); err != nil {
+				cfg.warnf("saving session cache: %v", err)
+			}
+		}()
+	}
+
+	var sessions []*SessionInfo
+	var errs []SessionError
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		session, err := parseCodexSessionCached(ctx, path, repoRoot, cfg, cache)
+		if ctx.Err() != nil {
+			// Interrupted mid-file; the file itself isn't at fault
+			break
+		}
+		if err != nil {
+			errs = append(errs, SessionError{Path: path, Err: err})
+		}
+		// A read error can still leave a usable partial session
+		if session != nil {
+			if cfg.Provenance {
+				session.recordSource(path)
+			}
+			sessions = append(sessions, session)
+		}
+	}
+
+	// On cancellation, return what was merged before it
+	if err := ctx.Err(); err != nil {
+		if len(sessions) == 0 {
+			return nil, err
+		}
+		merged := mergeCodexSessions(repoRoot, sessions, cfg)
+		if merged != nil {
+			merged.Errors = errs
+		}
+		return merged, err
+	}
+
+	// Only fail when every session file failed
+	if len(errs) > 0 && len(errs) == len(paths) && len(sessions) == 0 {
+		joined := make([]error, len(errs))
+		for i := range errs {
+			joined[i] = errs[i]
+		}
+		return nil, errors.Join(joined...)
+	}
+
+	merged := mergeCodexSessions(repoRoot, sessions, cfg)
+	if merged != nil {
+		merged.Errors = errs
 	}
 	return merged, nil
 }
+
+// DetectForRepos detects Codex sessions for several repo roots at once,
+// returning the merged session for each root that has one. The sessions
+// directory is listed once and each rollout read once, so this is much
+// cheaper than calling detectCodex per root. Sessions whose cwd matches
+// none of the roots are ignored.
+func DetectForRepos(repoRoots []string, maxAge time.Duration) map[string]*SessionInfo {
+	buckets := make(map[string][]*SessionInfo, len(repoRoots))
+	for _, root := range repoRoots {
+		buckets[root] = nil
+	}
+	paths, _ := findCodexSessions(context.Background(), maxAge)
+	for _, path := range paths {
+		session, err := parseCodexRollout(context.Background(), path, func(cwd string) bool {
+			_, ok := buckets[cwd]
+			return ok
+		}, DetectorConfig{})
+		if err != nil || session == nil {
+			continue
+		}
+		buckets[session.CWD] = append(buckets[session.CWD], session)
+	}
+
+	result := make(map[string]*SessionInfo)
+	for root, sessions := range buckets {
+		if len(sessions) == 0 {
+			continue
+		}
+		if merged := mergeCodexSessions(root, sessions, DetectorConfig{}); merged != nil {
+			result[root] = merged
+		}
+	}
+	return result
+}
+
+// mergeCodexSessions merges parsed rollouts for repoRoot into one session,
+// applying cfg. Returns nil if nothing was written.
+func mergeCodexSessions(repoRoot string, sessions []*SessionInfo, cfg DetectorConfig) *SessionInfo {
+	for _, session := range sessions {
+		session.Model = cfg.normalizeModel(session.Model)
+		if cfg.MatchSubdirs && session.CWD != "" {
+			// Paths are relative to the session cwd; make them relative to the repo
+			if rel, err := filepath.Rel(evalSymlinks(repoRoot), evalSymlinks(session.CWD)); err == nil && rel != "." {
+				session.rebase(filepath.ToSlash(rel))
+			}
+		}
+	}
+
+	sessions = collapseResumed(sessions)
+
+	if cfg.SameDayOnly {
+		sessions = latestDaySessions(sessions, cfg.location())
+	}
+
+	merged := mergeSessions(sessions, usageMax)
+	if merged == nil {
+		return nil
+	}
+
+	if drop := cfg.droppedPath(repoRoot); drop != nil {
+		merged.dropFiles(drop)
+	}
+	if cfg.AnonymizePaths {
+		merged.anonymizePaths()
+	}
+
+	if len(merged.FilesWritten) == 0 && len(merged.DirsWritten) == 0 {
+		return nil
+	}
+	merged.DirectoriesTouched = countDirs(merged.FilesWritten)
+	return merged
+}
+
+// droppedPath returns the predicate for written paths that cfg filters out
+// of a merged session: those matched by .gitignore with RespectGitignore,
+// generated files with SkipGenerated and files no longer on disk with
+// ExistingOnly. Each path is checked once. Returns nil when no filter is
+// enabled.
+func (c DetectorConfig) droppedPath(repoRoot string) func(p string) bool {
+	var checks []func(p string) bool
+	if c.RespectGitignore {
+		checks = append(checks, gitignored(repoRoot))
+	}
+	if c.SkipGenerated {
+		patterns := c.generatedPatterns()
+		checks = append(checks, func(p string) bool { return isGenerated(p, patterns) })
+	}
+	if c.ExistingOnly {
+		checks = append(checks, func(p string) bool { return !fileExists(repoRoot, p) })
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	dropped := make(map[string]bool)
+	return func(p string) bool {
+		drop, ok := dropped[p]
+		if !ok {
+			for _, check := range checks {
+				if drop = check(p); drop {
+					break
+				}
+			}
+			dropped[p] = drop
+		}
+		return drop
+	}
+}
+
+// fileExists reports whether the written path p is on disk. Relative
+// paths are resolved against repoRoot.
+func fileExists(repoRoot, p string) bool {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(repoRoot, filepath.FromSlash(p))
+	}
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+// dropFiles removes the written paths drop reports from every view of the
+// session: FilesWritten, FileActions, FileSources, Events and each turn's
+// files, so TokensPerFile agrees with FilesWritten.
+func (s *SessionInfo) dropFiles(drop func(p string) bool) {
+	for f := range s.FilesWritten {
+		if drop(f) {
+			delete(s.FilesWritten, f)
+		}
+	}
+	for f := range s.FileActions {
+		if drop(f) {
+			delete(s.FileActions, f)
+		}
+	}
+	for f := range s.FileSources {
+		if drop(f) {
+			delete(s.FileSources, f)
+		}
+	}
+	var events []FileEvent
+	for _, e := range s.Events {
+		if !drop(e.Path) {
+			events = append(events, e)
+		}
+	}
+	s.Events = events
+	for i, turn := range s.Turns {
+		var files []string
+		for _, f := range turn.FilesWritten {
+			if !drop(f) {
+				files = append(files, f)
+			}
+		}
+		s.Turns[i].FilesWritten = files
+	}
+}
+
+// rebase prefixes the session's relative paths with dir.
+func (s *SessionInfo) rebase(dir string) {
+	join := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return path.Join(dir, p)
+	}
+	rebaseSet := func(set map[string]struct{}) map[string]struct{} {
+		out := make(map[string]struct{}, len(set))
+		for p := range set {
+			out[join(p)] = struct{}{}
+		}
+		return out
+	}
+	s.FilesWritten = rebaseSet(s.FilesWritten)
+	s.DirsWritten = rebaseSet(s.DirsWritten)
+	s.FilesRead = rebaseSet(s.FilesRead)
+	s.FilesRenamedFrom = rebaseSet(s.FilesRenamedFrom)
+	actions := make(map[string]FileAction, len(s.FileActions))
+	for p, a := range s.FileActions {
+		actions[join(p)] = a
+	}
+	s.FileActions = actions
+	if s.FileSources != nil {
+		sources := make(map[string][]string, len(s.FileSources))
+		for p, srcs := range s.FileSources {
+			sources[join(p)] = srcs
+		}
+		s.FileSources = sources
+	}
+	for i, e := range s.Events {
+		s.Events[i].Path = join(e.Path)
+	}
+	for i, turn := range s.Turns {
+		files := make([]string, len(turn.FilesWritten))
+		for j, f := range turn.FilesWritten {
+			files[j] = join(f)
+		}
+		s.Turns[i].FilesWritten = files
+	}
+}
+
+// collapseResumed merges rollouts that share a session ID. Resuming a Codex
+// session writes a new rollout that replays the earlier turns, so only the
+// latest rollout's tokens, turns and timing are kept while file sets are
+// unioned. Sessions without an ID are returned unchanged.
+func collapseResumed(sessions []*SessionInfo) []*SessionInfo {
+	byID := make(map[string]*SessionInfo)
+	var result []*SessionInfo
+	for _, s := range sessions {
+		if s.SessionID == "" {
+			result = append(result, s)
+			continue
+		}
+		prev, ok := byID[s.SessionID]
+		if !ok {
+			byID[s.SessionID] = s
+			result = append(result, s)
+			continue
+		}
+		latest, earlier := s, prev
+		if prev.EndedAt.After(s.EndedAt) {
+			latest, earlier = prev, s
+		}
+		for f := range earlier.FilesWritten {
+			latest.FilesWritten[f] = struct{}{}
+		}
+		for d := range earlier.DirsWritten {
+			latest.DirsWritten[d] = struct{}{}
+		}
+		for f := range earlier.FilesRead {
+			latest.FilesRead[f] = struct{}{}
+		}
+		for f := range earlier.FilesRenamedFrom {
+			latest.FilesRenamedFrom[f] = struct{}{}
+		}
+		latest.RanTests = latest.RanTests || earlier.RanTests
+		for f, action := range earlier.FileActions {
+			if _, ok := latest.FileActions[f]; !ok {
+				latest.FileActions[f] = action
+			}
+		}
+		latest.mergeSources(earlier)
+		if latest != prev {
+			*prev = *latest
+		}
+	}
+	return result
+}
+
+// latestDaySessions returns the sessions that ended on the most recent
+// calendar day in loc. Sessions without timestamps are dropped, since their
+// day cannot be determined.
+func latestDaySessions(sessions []*SessionInfo, loc *time.Location) []*SessionInfo {
+	dayOf := func(t time.Time) string {
+		return t.In(loc).Format("2006-01-02")
+	}
+
+	var latest string
+	for _, s := range sessions {
+		if s.EndedAt.IsZero() {
+			continue
+		}
+		if d := dayOf(s.EndedAt); d > latest {
+			latest = d
+		}
+	}
+
+	var result []*SessionInfo
+	for _, s := range sessions {
+		if !s.EndedAt.IsZero() && dayOf(s.EndedAt) == latest {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -1,8 +1,16 @@
 package detector
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -18,6 +26,166 @@ func TestExtractFilesFromCmd(t *testing.T) {
 			cmd:  `cat > backend/app/main.py <<'EOF'\nfrom fastapi import FastAPI\nEOF`,
 			want: []string{"backend/app/main.py"},
 		},
+		{
+			name: "cat heredoc delimiter first",
+			cmd:  "cat <<EOF > main.go\npackage main\nEOF",
+			want: []string{"main.go"},
+		},
+		{
+			name: "cat heredoc quoted delimiter first",
+			cmd:  "cat <<'EOF' > cmd/main.go\npackage main\nEOF",
+			want: []string{"cmd/main.go"},
+		},
+		{
+			name: "cat heredoc append",
+			cmd:  "cat <<\"EOF\" >> notes.md\nmore\nEOF",
+			want: []string{"notes.md"},
+		},
+		{
+			name: "cat heredoc no space before delimiter",
+			cmd:  "cat >src/app.py<<'PY'\nprint(1)\nPY",
+			want: []string{"src/app.py"},
+		},
+		{
+			name: "cat heredoc piped to tee",
+			cmd:  "cat <<'EOF' | tee out.txt\nb\na\nEOF",
+			want: []string{"out.txt"},
+		},
+		{
+			name: "touch with trailing comment",
+			cmd:  `touch a.go # comment`,
+			want: []string{"a.go"},
+		},
+		{
+			name: "cat redirect with trailing comment",
+			cmd:  `cat > out.txt # write output`,
+			want: []string{"out.txt"},
+		},
+		{
+			name: "hash inside quotes is not a comment",
+			cmd:  `sed -i 's/ # TODO//' main.go # drop todos`,
+			want: []string{"main.go"},
+		},
+		{
+			name: "python -c open write",
+			cmd:  `python3 -c "import json; open('out.json', 'w').write(json.dumps({}))"`,
+			want: []string{"out.json"},
+		},
+		{
+			name: "python -c open read ignored",
+			cmd:  `python -c "print(open('in.json').read())"`,
+			want: nil,
+		},
+		{
+			name: "python -c pathlib write_text",
+			cmd:  `python -c "from pathlib import Path; Path('docs/notes.md').write_text('hi')"`,
+			want: []string{"docs/notes.md"},
+		},
+		{
+			name: "node -e writeFileSync",
+			cmd:  `node -e "require('fs').writeFileSync('gen/x.js', 'export {}')"`,
+			want: []string{"gen/x.js"},
+		},
+		{
+			name: "open outside inline script ignored",
+			cmd:  `grep -n "open('a.txt', 'w')" src/io.py`,
+			want: nil,
+		},
+		{
+			name: "heredoc followed by chained command",
+			cmd:  "cat > a.go <<'EOF'\npackage a\nEOF\ntouch b.go",
+			want: []string{"a.go", "b.go"},
+		},
+		{
+			name: "heredoc body is not analyzed",
+			cmd:  "cat <<EOF > README.md\nrun: cat x > not-a-file.txt\ntouch nope.go\nEOF\ncp README.md docs/README.md",
+			want: []string{"README.md", "docs/README.md"},
+		},
+		{
+			name: "heredoc with tab-stripped terminator",
+			cmd:  "cat <<-END > a.sh\n\techo hi > b.txt\n\tEND\ntouch c.go",
+			want: []string{"a.sh", "c.go"},
+		},
+		{
+			name: "two heredocs on one line",
+			cmd:  "cat > a.txt <<A; cat > b.txt <<B\nbody a > x\nA\nbody b > y\nB\ntouch c.txt",
+			want: []string{"a.txt", "b.txt", "c.txt"},
+		},
+		{
+			name: "tee multiple files",
+			cmd:  "tee a.txt b.txt",
+			want: []string{"a.txt", "b.txt"},
+		},
+		{
+			name: "tee append multiple files",
+			cmd:  "echo x | tee -a a.log b.log | grep x",
+			want: []string{"a.log", "b.log"},
+		},
+		{
+			name: "tee stops at redirect",
+			cmd:  "make 2>&1 | tee build.log > /dev/null",
+			want: []string{"build.log"},
+		},
+		{
+			name: "touch brace expansion",
+			cmd:  "touch src/{a,b,c}.go",
+			want: []string{"src/a.go", "src/b.go", "src/c.go"},
+		},
+		{
+			name: "nested brace expansion",
+			cmd:  "touch {cmd/{x,y},pkg}/main.go",
+			want: []string{"cmd/x/main.go", "cmd/y/main.go", "pkg/main.go"},
+		},
+		{
+			name: "quoted braces are literal",
+			cmd:  "touch 'src/{a,b}.go'",
+			want: []string{"src/{a,b}.go"},
+		},
+		{
+			name: "cp glob into directory",
+			cmd:  "cp templates/*.tpl out/",
+			want: nil,
+		},
+		{
+			name: "touch glob dropped",
+			cmd:  "touch *.go keep.go",
+			want: []string{"keep.go"},
+		},
+		{
+			name: "two heredocs sharing a delimiter",
+			cmd:  "cat > a.txt <<EOF && cat > b.txt <<'EOF'\ncat x > not-a.txt\nEOF\ntouch not-b.txt\nEOF\ntouch c.txt",
+			want: []string{"a.txt", "b.txt", "c.txt"},
+		},
+		{
+			name: "chained heredocs sharing a delimiter",
+			cmd:  "cat > a.go <<EOF &&\npackage a\nEOF\ncat > b.go <<EOF\ncat y > not-b.txt\nEOF\ntouch c.go",
+			want: []string{"a.go", "b.go", "c.go"},
+		},
+		{
+			name: "here-string is not a heredoc",
+			cmd:  "tee out.txt <<< hello\ntouch next.go",
+			want: []string{"out.txt", "next.go"},
+		},
+		{
+			name: "backslash continuation",
+			cmd:  "cp -r \\\n  src/a.go \\\n  dst/a.go",
+			want: []string{"dst/a.go"},
+		},
+		{
+			name: "envsubst template",
+			cmd:  `envsubst < template.conf > out.conf`,
+			want: []string{"out.conf"},
+		},
+		{
+			name: "envsubst with shell-format",
+			cmd:  `envsubst '$HOST $PORT' <nginx.tmpl >> conf/nginx.conf`,
+			want: []string{"conf/nginx.conf"},
+		},
+		{
+			name: "git stash",
+			cmd:  `git stash && git stash pop`,
+			want: nil,
+		},
 		{
 			name: "cat redirect",
 			cmd:  `cat > src/index.ts`,
@@ -53,11 +221,216 @@ func TestExtractFilesFromCmd(t *testing.T) {
 			cmd:  `mv src/old.go src/new.go`,
 			want: []string{"src/new.go"},
 		},
+		{
+			name: "cp with flags",
+			cmd:  `cp -f src/old.go src/new.go`,
+			want: []string{"src/new.go"},
+		},
+		{
+			name: "cp multiple sources into dir",
+			cmd:  `cp a.go b.go dest/`,
+			want: []string{"dest/a.go", "dest/b.go"},
+		},
+		{
+			name: "cp single source into dir",
+			cmd:  `cp -r templates/base.html public/`,
+			want: []string{"public/base.html"},
+		},
+		{
+			name: "cp with stderr redirect",
+			cmd:  `cp a.go b.go 2>/dev/null`,
+			want: []string{"b.go"},
+		},
+		{
+			name: "cp with stderr to stdout",
+			cmd:  `cp a.go b.go 2>&1`,
+			want: []string{"b.go"},
+		},
+		{
+			name: "cp quoted source with spaces",
+			cmd:  `cp "my file.go" dest.go`,
+			want: []string{"dest.go"},
+		},
+		{
+			name: "cp quoted sources into dir",
+			cmd:  `cp "my file.go" 'other file.go' dest/`,
+			want: []string{"dest/my file.go", "dest/other file.go"},
+		},
+		{
+			name: "mv multiple sources into dir without slash",
+			cmd:  `mv pkg/a.go pkg/b.go internal`,
+			want: []string{"internal/a.go", "internal/b.go"},
+		},
+		{
+			name: "mv with target directory flag",
+			cmd:  `mv -t src/ a.go b.go`,
+			want: []string{"src/a.go", "src/b.go"},
+		},
 		{
 			name: "sed in-place",
 			cmd:  `sed -i 's/old/new/g' config.yaml`,
 			want: []string{"config.yaml"},
 		},
+		{
+			name: "sed in-place two files",
+			cmd:  `sed -i 's/a/b/' file1.go file2.go`,
+			want: []string{"file1.go", "file2.go"},
+		},
+		{
+			name: "sed in-place three files",
+			cmd:  `sed -i 's/a/b/' a.go b.go c.go && go build ./...`,
+			want: []string{"a.go", "b.go", "c.go"},
+		},
+		{
+			name: "sed in-place with stderr redirect",
+			cmd:  `sed -i 's/a/b/' f.go 2>/dev/null`,
+			want: []string{"f.go"},
+		},
+		{
+			name: "sed in-place over command substitution",
+			cmd:  `sed -i 's/a/b/' $(git ls-files '*.go')`,
+			want: nil,
+		},
+		{
+			name: "sed in-place over backtick substitution",
+			cmd:  "sed -i 's/a/b/' `find . -name '*.go'` main.go",
+			want: []string{"main.go"},
+		},
+		{
+			name: "touch with stderr redirect",
+			cmd:  `touch a.go 2>/dev/null`,
+			want: []string{"a.go"},
+		},
+		{
+			name: "tee with stderr redirect",
+			cmd:  `echo x | tee out.txt 2>&1`,
+			want: []string{"out.txt"},
+		},
+		{
+			name: "touch command substitution",
+			cmd:  `touch "$(date +%s).log" a.go`,
+			want: []string{"a.go"},
+		},
+		{
+			name: "sed in-place backup suffix",
+			cmd:  `sed -i.bak 's/a/b/' a.go b.go`,
+			want: []string{"a.go", "b.go"},
+		},
+		{
+			name: "sed in-place attached empty suffix",
+			cmd:  `sed -i'' 's/a/b/' a.go b.go`,
+			want: []string{"a.go", "b.go"},
+		},
+		{
+			name: "sed in-place bsd empty suffix",
+			cmd:  `sed -i '' 's/a/b/' a.go b.go`,
+			want: []string{"a.go", "b.go"},
+		},
+		{
+			name: "sed gnu in-place",
+			cmd:  `sed --in-place 's/a/b/' x.go`,
+			want: []string{"x.go"},
+		},
+		{
+			name: "sed gnu in-place backup suffix",
+			cmd:  `sed --in-place=.bak 's/a/b/' x.go`,
+			want: []string{"x.go"},
+		},
+		{
+			name: "sed gnu in-place with expression",
+			cmd:  `sed --in-place -e 's/a/b/' x.go y.go`,
+			want: []string{"x.go", "y.go"},
+		},
+		{
+			name: "sed without in-place ignored",
+			cmd:  `sed 's/a/b/' x.go`,
+			want: nil,
+		},
+		{
+			name: "install with mode",
+			cmd:  "install -m 0644 build/app.conf etc/app.conf",
+			want: []string{"etc/app.conf"},
+		},
+		{
+			name: "install creating leading directories",
+			cmd:  "install -D src a/b/c",
+			want: []string{"a/b/c"},
+		},
+		{
+			name: "install into directory",
+			cmd:  "mkdir -p bin && install -m 755 -o root tool1 tool2 bin/",
+			want: []string{"bin/tool1", "bin/tool2"},
+		},
+		{
+			name: "install directories only",
+			cmd:  "install -d out/logs",
+			want: nil,
+		},
+		{
+			name: "package manager install ignored",
+			cmd:  "npm install react react-dom && pip install requests six",
+			want: nil,
+		},
+		{
+			name: "ln symlink",
+			cmd:  "ln -sf ../shared/config.yaml config.yaml",
+			want: []string{"config.yaml"},
+		},
+		{
+			name: "ln into directory",
+			cmd:  "ln -s ../a.go ../b.go links/",
+			want: []string{"links/a.go", "links/b.go"},
+		},
+		{
+			name: "ln lone target",
+			cmd:  "ln -s ../shared/Makefile",
+			want: []string{"Makefile"},
+		},
+		{
+			name: "rsync file",
+			cmd:  "rsync a.txt b.txt",
+			want: []string{"b.txt"},
+		},
+		{
+			name: "rsync directory",
+			cmd:  "rsync -a a/ b/",
+			want: nil,
+		},
+		{
+			name: "dd of operand",
+			cmd:  "dd if=x of=out.bin",
+			want: []string{"out.bin"},
+		},
+		{
+			name: "dd with block operands",
+			cmd:  "dd if=/dev/zero of=bigfile bs=1M count=10",
+			want: []string{"bigfile"},
+		},
+		{
+			name: "dd to dev null",
+			cmd:  "dd if=big.iso of=/dev/null bs=4M",
+			want: nil,
+		},
+		{
+			name: "dd to stdout",
+			cmd:  "dd if=header.bin bs=16 count=1 | xxd",
+			want: nil,
+		},
+		{
+			name: "touch in subshell",
+			cmd:  "(touch a.go)",
+			want: []string{"a.go"},
+		},
+		{
+			name: "touch with trailing semicolon",
+			cmd:  "touch a.go; ls",
+			want: []string{"a.go"},
+		},
+		{
+			name: "subshell list",
+			cmd:  "(cd backend; touch config.yaml) && ls",
+			want: []string{"config.yaml"},
+		},
 		{
 			name: "mkdir ignored",
 			cmd:  `mkdir -p backend/app/core backend/app/routers`,
@@ -83,6 +456,111 @@ func TestExtractFilesFromCmd(t *testing.T) {
 			cmd:  `cat > /dev/null`,
 			want: nil,
 		},
+		{
+			name: "cp from stdin",
+			cmd:  `cp /dev/stdin out.txt`,
+			want: []string{"out.txt"},
+		},
+		{
+			name: "cat stdin redirect",
+			cmd:  `cat /dev/stdin > out.txt`,
+			want: []string{"out.txt"},
+		},
+		{
+			name: "cat noclobber override",
+			cmd:  `cat >| out.txt <<'EOF'\nhello\nEOF`,
+			want: []string{"out.txt"},
+		},
+		{
+			name: "cat append noclobber override",
+			cmd:  `cat notes.txt >>| "log.txt"`,
+			want: []string{"log.txt"},
+		},
+		{
+			name: "envsubst noclobber override",
+			cmd:  `envsubst < tmpl.yaml >| deploy.yaml`,
+			want: []string{"deploy.yaml"},
+		},
+		{
+			name: "perl in-place",
+			cmd:  `perl -i -pe 's/x/y/' a.go b.go`,
+			want: []string{"a.go", "b.go"},
+		},
+		{
+			name: "perl clustered switches with backup suffix",
+			cmd:  `perl -pi.bak -e "s/old name/new name/g" src/main.go && go build`,
+			want: []string{"src/main.go"},
+		},
+		{
+			name: "perl -n in-place",
+			cmd:  `perl -ni -e 'print unless /DEBUG -i/' log.txt`,
+			want: []string{"log.txt"},
+		},
+		{
+			name: "perl without -i only reads",
+			cmd:  `perl -ne 'print if / -i /' a.go`,
+			want: nil,
+		},
+		{
+			name: "awk shell redirect",
+			cmd:  `awk -F, '$3 > 100 {print $1}' data.csv > big.txt`,
+			want: []string{"big.txt"},
+		},
+		{
+			name: "awk program redirect",
+			cmd:  `awk '{print > "out.txt"}' in.txt`,
+			want: []string{"out.txt"},
+		},
+		{
+			name: "awk printf append in program",
+			cmd:  `gawk -v n=2 '/ERROR/ {printf "%s\n", $0 >> "errors.log"; next} {print}' app.log`,
+			want: []string{"errors.log"},
+		},
+		{
+			name: "awk computed target skipped",
+			cmd:  `awk '{print > ("out_" $1 ".txt")}' in.txt; awk '$2 > 5' in.txt`,
+			want: nil,
+		},
+		{
+			name: "colon truncate",
+			cmd:  `: > a.log`,
+			want: []string{"a.log"},
+		},
+		{
+			name: "bare truncate",
+			cmd:  `> b.log`,
+			want: []string{"b.log"},
+		},
+		{
+			name: "bare truncate after separator",
+			cmd:  `mkdir -p logs && > logs/run.log; :>| logs/err.log`,
+			want: []string{"logs/run.log", "logs/err.log"},
+		},
+		{
+			name: "command redirects not bare",
+			cmd:  `go test ./... > test.log 2>&1; make &> build.log`,
+			want: nil,
+		},
+		{
+			name: "nested bash -c",
+			cmd:  "bash -c \"touch a.go && sh -c 'cat > b.go <<EOF\nfrom x import y\nEOF'\"",
+			want: []string{"b.go", "a.go"},
+		},
+		{
+			name: "bash -lc single quotes",
+			cmd:  `bash -lc 'cp src.txt dst.txt; touch it'\''s.md'`,
+			want: []string{"it's.md", "dst.txt"},
+		},
+		{
+			name: "sh -c escaped double quotes",
+			cmd:  `sh -c "cat src.txt > \"out/dst.txt\""`,
+			want: []string{"out/dst.txt"},
+		},
+		{
+			name: "unterminated wrapper left alone",
+			cmd:  `bash -c "touch a.go`,
+			want: []string{"a.go"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -101,6 +579,25 @@ func TestExtractFilesFromCmd(t *testing.T) {
 	}
 }
 
+func TestUnwrapShellCommands(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{`bash -c "go test ./..."`, `go test ./...`},
+		{`cd repo && bash -lc 'make build' && echo done`, `cd repo && make build && echo done`},
+		{`bash -c "sh -c 'touch a.go'"`, `touch a.go`},
+		{`zsh -c "echo \"\$HOME\""`, `echo "$HOME"`},
+		{`bash script.sh`, `bash script.sh`},
+		{`ssh -c aes128-ctr host`, `ssh -c aes128-ctr host`},
+	}
+	for _, tt := range tests {
+		if got := unwrapShellCommands(tt.cmd); got != tt.want {
+			t.Errorf("unwrapShellCommands(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
 func TestCleanPath(t *testing.T) {
 	tests := []struct {
 		input string
@@ -111,10 +608,35 @@ func TestCleanPath(t *testing.T) {
 		{"<<EOF", ""},
 		{"<<'EOF'", ""},
 		{"/dev/null", ""},
+		{"/dev/stdin", ""},
 		{"-rf", ""},
 		{"src/", ""},
 		{"", ""},
 		{"main.go", "main.go"},
+		{`src\main.go`, "src/main.go"},
+		{"./src//main.go", "src/main.go"},
+		{"./a.go", "a.go"},
+		{"src/./main.go", "src/main.go"},
+		{"/repo/./src/main.go", "/repo/src/main.go"},
+		{".", ""},
+		{"$(git", ""},
+		{`"$(pwd)/a.go"`, ""},
+		{"`ls`", ""},
+		{`.\src\\main.go`, "src/main.go"},
+		{`src\lib/`, ""},
+		{`C:\repo\main.go`, "C:/repo/main.go"},
+		{`my\ file.go`, `my\ file.go`},
+		{"src/*.go", ""},
+		{"a.go;", "a.go"},
+		{"a.go);", "a.go"},
+		{"(a.go", "a.go"},
+		{"config.yaml)", "config.yaml"},
+		{"a.go,", "a.go"},
+		{"a.go&", "a.go"},
+		{"a.go|", "a.go"},
+		{"file(1).txt", "file(1).txt"},
+		{"(file(1).txt", "file(1).txt"},
+		{";", ""},
 	}
 
 	for _, tt := range tests {
@@ -127,6 +649,63 @@ func TestCleanPath(t *testing.T) {
 	}
 }
 
+func TestShellFields(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"a.go  b.go", []string{"a.go", "b.go"}},
+		{`"my file.go" 'x y' z`, []string{`"my file.go"`, `'x y'`, "z"}},
+		{`my\ file.go out`, []string{`my\ file.go`, "out"}},
+		{`$(git ls-files '*.go') a.go`, []string{`$(git ls-files '*.go')`, "a.go"}},
+		{"`find . -name x` a.go", []string{"`find . -name x`", "a.go"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := shellFields(tt.input); !equal(got, tt.want) {
+			t.Errorf("shellFields(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"src/{a,b}.go", []string{"src/a.go", "src/b.go"}},
+		{"{a,b}/{x,y}", []string{"a/x", "a/y", "b/x", "b/y"}},
+		{"a{,.bak}", []string{"a", "a.bak"}},
+		{"${DIR}/{a,b}.go", []string{"${DIR}/a.go", "${DIR}/b.go"}},
+		{"${DIR}/a.go", []string{"${DIR}/a.go"}},
+		{"src/{a,b.go", []string{"src/{a,b.go"}},
+		{`"src/{a,b}.go"`, []string{`"src/{a,b}.go"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := expandBraces(tt.input); !equal(got, tt.want) {
+				t.Errorf("expandBraces(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanPath_Tilde(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	if got, want := cleanPath("~/x"), filepath.Join(homeDir, "x"); got != want {
+		t.Errorf("cleanPath(~/x) = %q, want %q", got, want)
+	}
+	if got := cleanPath("~alice/x"); got != "" {
+		t.Errorf("cleanPath(~alice/x) = %q, want dropped", got)
+	}
+	if got := extractFilesFromCmd(`cat > ~other/project/a.go`); got != nil {
+		t.Errorf("expected ~user path dropped, got %v", got)
+	}
+}
+
 const testCodexJSONL = `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"id":"019c4716","timestamp":"2026-02-10T10:25:57.659Z","cwd":"/Users/jose/myproject","originator":"codex_cli","source":"cli","model_provider":"openai"}}
 {"timestamp":"2026-02-10T10:25:57.753Z","type":"turn_context","payload":{"cwd":"/Users/jose/myproject","model":"gpt-5.3-codex"}}
 {"timestamp":"2026-02-10T10:25:58.000Z","type":"event_msg","payload":{"type":"user_message","message":"create a main.go file"}}
@@ -138,7 +717,7 @@ const testCodexJSONL = `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_
 
 func TestParseCodexSession_Basic(t *testing.T) {
 	path := writeTestJSONL(t, testCodexJSONL)
-	info, err := parseCodexSession(path)
+	info, err := parseCodexSession(context.Background(), path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,11 +735,20 @@ func TestParseCodexSession_Basic(t *testing.T) {
 	if info.Model != "gpt-5.3-codex" {
 		t.Errorf("model: got %q, want %q", info.Model, "gpt-5.3-codex")
 	}
+	if info.Provider != "openai" {
+		t.Errorf("provider: got %q, want %q", info.Provider, "openai")
+	}
+	if info.Originator != "codex_cli" || info.Source != "cli" {
+		t.Errorf("originator/source: got %q/%q, want codex_cli/cli", info.Originator, info.Source)
+	}
 
 	// Token usage: last total_token_usage.total_tokens = 18521
 	if info.TotalTokens != 18521 {
 		t.Errorf("tokens: got %d, want %d", info.TotalTokens, 18521)
 	}
+	if info.InputTokens != 17992 || info.OutputTokens != 529 {
+		t.Errorf("token split: got %d/%d, want 17992/529", info.InputTokens, info.OutputTokens)
+	}
 
 	// Session duration: 10:25:57.694 to 10:27:30.040 ≈ 92 seconds
 	if info.SessionDurationSec < 90 || info.SessionDurationSec > 95 {
@@ -178,7 +766,7 @@ func TestParseCodexSession_NoWrites(t *testing.T) {
 {"timestamp":"2026-02-10T10:26:00.000Z","type":"event_msg","payload":{"type":"user_message","message":"hello"}}`
 
 	path := writeTestJSONL(t, content)
-	info, err := parseCodexSession(path)
+	info, err := parseCodexSession(context.Background(), path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -194,7 +782,7 @@ this is not valid json
 {"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch b.go\"}"}}`
 
 	path := writeTestJSONL(t, content)
-	info, err := parseCodexSession(path)
+	info, err := parseCodexSession(context.Background(), path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -209,16 +797,49 @@ this is not valid json
 	}
 }
 
-func TestExtractFilesFromPatch(t *testing.T) {
-	tests := []struct {
-		name  string
-		input string
-		want  []string
-	}{
-		{
-			name:  "single file",
-			input: "*** Begin Patch\n*** Update File: src/main.go\n@@ -1,3 +1,4 @@\n+import \"fmt\"\n",
-			want:  []string{"src/main.go"},
+func TestParseCodexSessionFor_MultiLineRecords(t *testing.T) {
+	content := `{
+  "timestamp": "2026-02-10T10:25:57.694Z",
+  "type": "session_meta",
+  "payload": {
+    "cwd": "/Users/jose/myproject"
+  }
+}
+{
+  "timestamp": "2026-02-10T10:26:00.000Z",
+  "type": "response_item",
+  "payload": {
+    "type": "function_call",
+    "name": "exec_command",
+    "arguments": "{\"cmd\":\"touch a.go\"}"
+  }
+}
+{"timestamp":"2026-02-10T10:26:30.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch b.go\"}"}}
+{"truncated record
+{"timestamp":"2026-02-10T10:27:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch c.go\"}"}}`
+
+	info, err := parseCodexSessionFor(context.Background(), writeTestJSONL(t, content), testRepoRoot, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected the pretty-printed session_meta to match")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"a.go", "b.go", "c.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+}
+
+func TestExtractFilesFromPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "single file",
+			input: "*** Begin Patch\n*** Update File: src/main.go\n@@ -1,3 +1,4 @@\n+import \"fmt\"\n",
+			want:  []string{"src/main.go"},
 		},
 		{
 			name:  "multiple files",
@@ -230,6 +851,11 @@ func TestExtractFilesFromPatch(t *testing.T) {
 			input: "*** Update File: a.go\n@@ ...\n*** Update File: a.go\n@@ ...\n",
 			want:  []string{"a.go"},
 		},
+		{
+			name:  "dot slash prefix deduped",
+			input: "*** Update File: ./a.go\n@@ ...\n*** Update File: a.go\n@@ ...\n*** Update File: src/./b.go\n",
+			want:  []string{"a.go", "src/b.go"},
+		},
 		{
 			name:  "no update file lines",
 			input: "*** Begin Patch\nsome other content\n",
@@ -239,7 +865,10 @@ func TestExtractFilesFromPatch(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := extractFilesFromPatch(tt.input)
+			got, renamed := extractFilesFromPatch(tt.input)
+			if renamed != nil {
+				t.Errorf("renamed: got %v, want none", renamed)
+			}
 			if len(got) != len(tt.want) {
 				t.Errorf("got %v, want %v", got, tt.want)
 				return
@@ -253,11 +882,56 @@ func TestExtractFilesFromPatch(t *testing.T) {
 	}
 }
 
+func TestParseCodexSession_DotSlashDedup(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch ./a.go ./src/main.go\"}"}}
+{"timestamp":"2026-02-10T10:27:00.000Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: a.go\n@@\n+x\n*** Update File: ./src/main.go\n@@\n+y\n"}}`
+
+	info, err := parseCodexSession(context.Background(), writeTestJSONL(t, content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"a.go", "src/main.go"}) {
+		t.Errorf("files: got %v, want [a.go src/main.go]", got)
+	}
+}
+
+func TestExtractFilesFromPatch_MoveTo(t *testing.T) {
+	input := "*** Begin Patch\n*** Update File: src/old.go\n*** Move to: src/new.go\n@@\n-a\n+b\n*** Update File: main.go\n@@\n+c\n*** End Patch"
+	files, renamed := extractFilesFromPatch(input)
+	if !equal(files, []string{"src/new.go", "main.go"}) {
+		t.Errorf("files: got %v", files)
+	}
+	if !equal(renamed, []string{"src/old.go"}) {
+		t.Errorf("renamed: got %v", renamed)
+	}
+}
+
+func TestParseCodexSession_MovedFile(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: pkg/util.go\n*** Move to: pkg/strings.go\n*** End Patch"}}`
+
+	info, err := parseCodexReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"pkg/strings.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+	if got := sortedKeys(info.FilesRenamedFrom); !equal(got, []string{"pkg/util.go"}) {
+		t.Errorf("renamed from: got %v", got)
+	}
+}
+
 func TestParseCodexSession_ApplyPatch(t *testing.T) {
 	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: src/main.go\n@@ -1,3 +1,4 @@\n+import \"fmt\"\n"}}`
 
-	path := writeTestJSONL(t, content)
-	info, err := parseCodexSession(path)
+	info, err := parseCodexReader(strings.NewReader(content))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -275,8 +949,7 @@ func TestParseCodexSession_ApplyPatch(t *testing.T) {
 func TestParseCodexSession_ApplyPatchMultiFile(t *testing.T) {
 	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: src/main.go\n@@ -1,3 +1,4 @@\n+line\n*** Update File: src/utils.go\n@@ -5,2 +5,3 @@\n+line\n"}}`
 
-	path := writeTestJSONL(t, content)
-	info, err := parseCodexSession(path)
+	info, err := parseCodexReader(strings.NewReader(content))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -291,13 +964,38 @@ func TestParseCodexSession_ApplyPatchMultiFile(t *testing.T) {
 	}
 }
 
+func TestParseCodexSessionRaw_NoWrites(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:25:57.753Z","type":"turn_context","payload":{"model":"gpt-5-codex"}}
+{"timestamp":"2026-02-10T10:27:57.694Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":900,"output_tokens":100,"total_tokens":1000}}}}`
+	path := writeTestJSONL(t, content)
+
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil || info != nil {
+		t.Fatalf("strict parse: got %+v, %v, want nil for a session without writes", info, err)
+	}
+
+	info, err = parseCodexSessionRaw(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("raw parse: expected the session despite no writes")
+	}
+	if info.TotalTokens != 1000 || info.InputTokens != 900 || info.Model != "gpt-5-codex" {
+		t.Errorf("got tokens=%d input=%d model=%q", info.TotalTokens, info.InputTokens, info.Model)
+	}
+	if info.SessionDurationSec != 120 || len(info.FilesWritten) != 0 {
+		t.Errorf("got duration=%d files=%v", info.SessionDurationSec, sortedKeys(info.FilesWritten))
+	}
+}
+
 func TestParseCodexSession_ModelUpdate(t *testing.T) {
 	content := `{"timestamp":"2026-02-10T10:25:57.753Z","type":"turn_context","payload":{"model":"gpt-5-codex"}}
 {"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}
 {"timestamp":"2026-02-10T10:27:00.000Z","type":"turn_context","payload":{"model":"gpt-5.3-codex"}}`
 
-	path := writeTestJSONL(t, content)
-	info, err := parseCodexSession(path)
+	info, err := parseCodexReader(strings.NewReader(content))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -306,32 +1004,130 @@ func TestParseCodexSession_ModelUpdate(t *testing.T) {
 	}
 }
 
-func TestMatchesRepo(t *testing.T) {
+func TestParseCodexReader_MatchesFile(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:25:57.753Z","type":"turn_context","payload":{"model":"gpt-5-codex"}}
+` + testCodexTouchLine + `
+{"timestamp":"2026-02-10T10:27:00.000Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":100,"output_tokens":20,"total_tokens":120}}}}`
+
+	fromFile, err := parseCodexSession(context.Background(), writeTestJSONL(t, content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromReader, err := parseCodexReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromReader, fromFile) {
+		t.Errorf("reader: got %+v, want %+v", fromReader, fromFile)
+	}
+}
+
+func TestParseCodexSession_EndReason(t *testing.T) {
+	event := func(payload string) string {
+		return `{"timestamp":"2026-02-10T10:27:00.000Z","type":"event_msg","payload":` + payload + `}` + "\n"
+	}
+	started := event(`{"type":"task_started"}`)
+	complete := event(`{"type":"task_complete","last_agent_message":"done"}`)
+
+	tests := []struct {
+		name            string
+		events          string
+		wantReason      string
+		wantInterrupted bool
+	}{
+		{"completed", started + complete, "completed", false},
+		{"aborted", started + event(`{"type":"turn_aborted","reason":"interrupted"}`), "interrupted", true},
+		{"error", started + event(`{"type":"error","message":"stream disconnected"}`), "error", true},
+		{"recovered", started + event(`{"type":"error","message":"x"}`) + started + complete, "completed", false},
+		{"still running", complete + started, "", false},
+		{"no lifecycle events", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := parseCodexReader(strings.NewReader(testCodexTouchLine + "\n" + tt.events))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.EndReason != tt.wantReason || info.Interrupted != tt.wantInterrupted {
+				t.Errorf("got reason=%q interrupted=%v, want %q/%v", info.EndReason, info.Interrupted, tt.wantReason, tt.wantInterrupted)
+			}
+		})
+	}
+}
+
+// testCodexTouchLine is a rollout line that writes a.go, so a parsed
+// session is non-nil exactly when its cwd matched.
+const testCodexTouchLine = `{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}`
+
+func sessionMatches(t *testing.T, path, repoRoot string) bool {
+	t.Helper()
+	info, err := parseCodexSessionFor(context.Background(), path, repoRoot, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info != nil
+}
+
+func TestParseCodexSessionFor(t *testing.T) {
 	// Matching cwd
-	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}`
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine
 	path := writeTestJSONL(t, content)
-	if !matchesRepo(path, "/Users/jose/myproject") {
+	if !sessionMatches(t, path, "/Users/jose/myproject") {
 		t.Error("expected match for same cwd")
 	}
 
 	// Non-matching cwd
-	if matchesRepo(path, "/Users/jose/other-project") {
+	if sessionMatches(t, path, "/Users/jose/other-project") {
 		t.Error("expected no match for different cwd")
 	}
 }
 
-func TestMatchesRepo_InvalidFile(t *testing.T) {
+func TestParseCodexSessionFor_InvalidFile(t *testing.T) {
 	path := writeTestJSONL(t, "not valid json")
-	if matchesRepo(path, "/Users/jose/myproject") {
+	if sessionMatches(t, path, "/Users/jose/myproject") {
 		t.Error("expected no match for invalid file")
 	}
 }
 
-func TestMatchesRepo_NonSessionMeta(t *testing.T) {
-	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"turn_context","payload":{"model":"gpt-5.3-codex"}}`
+func TestParseCodexSessionFor_NonSessionMeta(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"turn_context","payload":{"model":"gpt-5.3-codex"}}
+` + testCodexTouchLine
+	path := writeTestJSONL(t, content)
+	if sessionMatches(t, path, "/Users/jose/myproject") {
+		t.Error("expected no match without a session_meta record")
+	}
+}
+
+func TestParseCodexSessionFor_MetaOnSecondLine(t *testing.T) {
+	content := `
+{"timestamp":"2026-02-10T10:25:57.600Z","type":"turn_context","payload":{"model":"gpt-5.3-codex"}}
+{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSessionFor(context.Background(), path, "/Users/jose/myproject", DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected match when session_meta follows a turn_context")
+	}
+	if info.Model != "gpt-5.3-codex" {
+		t.Errorf("model from leading turn_context: got %q", info.Model)
+	}
+}
+
+func TestParseCodexSessionFor_MetaTooLate(t *testing.T) {
+	var content string
+	for i := 0; i < codexMetaScanLines; i++ {
+		content += `{"timestamp":"2026-02-10T10:25:57.600Z","type":"turn_context","payload":{"model":"gpt-5.3-codex"}}` + "\n"
+	}
+	content += `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine
 	path := writeTestJSONL(t, content)
-	if matchesRepo(path, "/Users/jose/myproject") {
-		t.Error("expected no match when first line is not session_meta")
+	if sessionMatches(t, path, "/Users/jose/myproject") {
+		t.Errorf("expected no match when session_meta is beyond the first %d lines", codexMetaScanLines)
 	}
 }
 
@@ -339,7 +1135,6 @@ func TestFindCodexSessions(t *testing.T) {
 	homeDir := t.TempDir()
 	t.Setenv("HOME", homeDir)
 
-	repoRoot := "/Users/jose/myproject"
 	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
 	if err := os.MkdirAll(sessionDir, 0755); err != nil {
 		t.Fatal(err)
@@ -369,16 +1164,86 @@ func TestFindCodexSessions(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	sessions, err := findCodexSessions(repoRoot, 72*time.Hour)
+	// Only modtime is checked; cwd filtering happens while parsing
+	sessions, err := findCodexSessions(context.Background(), 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d: %v", len(sessions), sessions)
+	}
+	if sessions[0] != matchPath || sessions[1] != otherPath {
+		t.Errorf("got %v, want [%s %s]", sessions, matchPath, otherPath)
+	}
+}
+
+func TestFindCodexSessions_CodexHome(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	codexHome := t.TempDir()
+	t.Setenv("CODEX_HOME", codexHome)
+	xdgHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", xdgHome)
+
+	write := func(root string) string {
+		t.Helper()
+		dir := filepath.Join(root, "sessions", "2026", "02", "10")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		p := filepath.Join(dir, "rollout-2026-02-10T10-25-57-abc123.jsonl")
+		if err := os.WriteFile(p, []byte(testCodexTouchLine), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	inCodexHome := write(codexHome)
+	inXDG := write(filepath.Join(xdgHome, "codex"))
+	write(filepath.Join(homeDir, ".codex"))
+
+	sessions, err := findCodexSessions(context.Background(), 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(sessions, []string{inCodexHome}) {
+		t.Errorf("CODEX_HOME: got %v, want [%s]", sessions, inCodexHome)
+	}
+
+	// A CODEX_HOME that doesn't exist falls through to XDG_DATA_HOME
+	t.Setenv("CODEX_HOME", filepath.Join(codexHome, "missing"))
+	sessions, err = findCodexSessions(context.Background(), 72*time.Hour)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if !equal(sessions, []string{inXDG}) {
+		t.Errorf("XDG_DATA_HOME: got %v, want [%s]", sessions, inXDG)
+	}
+}
+
+func TestFindCodexSessions_FlatLayout(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionsDir := filepath.Join(homeDir, ".codex", "sessions")
+	nestedDir := filepath.Join(sessionsDir, "2026", "02", "10")
+	if err := os.MkdirAll(nestedDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	flat := filepath.Join(sessionsDir, "rollout-2025-08-01-abc123.jsonl")
+	nested := filepath.Join(nestedDir, "rollout-2026-02-10T10-25-57-def456.jsonl")
+	for _, p := range []string{flat, nested} {
+		if err := os.WriteFile(p, []byte(testCodexTouchLine), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
 
-	if len(sessions) != 1 {
-		t.Fatalf("expected 1 session, got %d: %v", len(sessions), sessions)
+	sessions, err := findCodexSessions(context.Background(), 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
 	}
-	if sessions[0] != matchPath {
-		t.Errorf("got %q, want %q", sessions[0], matchPath)
+	if !equal(sessions, []string{flat, nested}) {
+		t.Errorf("got %v, want [%s %s]", sessions, flat, nested)
 	}
 }
 
@@ -386,7 +1251,7 @@ func TestFindCodexSessions_NoSessionsDir(t *testing.T) {
 	homeDir := t.TempDir()
 	t.Setenv("HOME", homeDir)
 
-	sessions, err := findCodexSessions("/some/repo", 72*time.Hour)
+	sessions, err := findCodexSessions(context.Background(), 72*time.Hour)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -406,7 +1271,7 @@ func TestDetectCodex_MergesSessions(t *testing.T) {
 	}
 
 	// Session 1: writes a.go
-	session1 := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+	session1 := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject","originator":"codex_vscode","source":"vscode"}}
 {"timestamp":"2026-02-10T10:25:57.753Z","type":"turn_context","payload":{"model":"gpt-5-codex"}}
 {"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}`
 	path1 := filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl")
@@ -415,7 +1280,7 @@ func TestDetectCodex_MergesSessions(t *testing.T) {
 	}
 
 	// Session 2: writes b.go with newer model
-	session2 := `{"timestamp":"2026-02-10T11:00:00.000Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+	session2 := `{"timestamp":"2026-02-10T11:00:00.000Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject","originator":"codex_cli","source":"cli"}}
 {"timestamp":"2026-02-10T11:00:00.100Z","type":"turn_context","payload":{"model":"gpt-5.3-codex"}}
 {"timestamp":"2026-02-10T11:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch b.go\"}"}}`
 	path2 := filepath.Join(sessionDir, "rollout-2026-02-10T11-00-00-bbb.jsonl")
@@ -423,7 +1288,7 @@ func TestDetectCodex_MergesSessions(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	info, err := detectCodex(repoRoot, 72*time.Hour)
+	info, err := detectCodex(context.Background(), repoRoot, 72*time.Hour, DetectorConfig{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -440,4 +1305,1351 @@ func TestDetectCodex_MergesSessions(t *testing.T) {
 	if info.Model != "gpt-5.3-codex" {
 		t.Errorf("model: got %q, want %q", info.Model, "gpt-5.3-codex")
 	}
+	if info.Originator != "codex_cli" || info.Source != "cli" {
+		t.Errorf("originator/source: got %q/%q, want the latest session's", info.Originator, info.Source)
+	}
+}
+
+func TestDetectCodex_SameDayOnly(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	repoRoot := "/Users/jose/myproject"
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Yesterday's session: writes old.go
+	yesterday := `{"timestamp":"2026-02-09T15:00:00.000Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-09T15:00:00.100Z","type":"turn_context","payload":{"model":"gpt-5-codex"}}
+{"timestamp":"2026-02-09T15:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch old.go\"}"}}`
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-09T15-00-00-aaa.jsonl"), []byte(yesterday), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Today's session: writes new.go
+	today := `{"timestamp":"2026-02-10T09:00:00.000Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T09:00:00.100Z","type":"turn_context","payload":{"model":"gpt-5.3-codex"}}
+{"timestamp":"2026-02-10T09:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch new.go\"}"}}`
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T09-00-00-bbb.jsonl"), []byte(today), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := detectCodex(context.Background(), repoRoot, 72*time.Hour, DetectorConfig{SameDayOnly: true, Location: time.UTC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+
+	wantFiles := []string{"new.go"}
+	gotFiles := sortedKeys(info.FilesWritten)
+	if !equal(gotFiles, wantFiles) {
+		t.Errorf("files: got %v, want %v", gotFiles, wantFiles)
+	}
+	if info.Model != "gpt-5.3-codex" {
+		t.Errorf("model: got %q, want %q", info.Model, "gpt-5.3-codex")
+	}
+
+	// Without the option both days are merged
+	info, err = detectCodex(context.Background(), repoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"new.go", "old.go"}) {
+		t.Errorf("files without SameDayOnly: got %v", got)
+	}
+}
+
+func TestTokensPerFile(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go b.go\"}"}}
+{"timestamp":"2026-02-10T10:26:10.000Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1000},"last_token_usage":{"total_tokens":1000}}}}
+{"timestamp":"2026-02-10T10:27:00.000Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: c.go\n@@ -1 +1 @@\n+x\n"}}
+{"timestamp":"2026-02-10T10:27:10.000Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":4001},"last_token_usage":{"total_tokens":3001}}}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if len(info.Turns) != 2 {
+		t.Fatalf("turns: got %d, want 2", len(info.Turns))
+	}
+
+	got := info.TokensPerFile()
+	want := map[string]int64{"a.go": 500, "b.go": 500, "c.go": 3001}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for f, n := range want {
+		if got[f] != n {
+			t.Errorf("%s: got %d, want %d", f, got[f], n)
+		}
+	}
+}
+
+func TestSessionInfoRates(t *testing.T) {
+	tests := []struct {
+		name               string
+		s                  *SessionInfo
+		perFile, perMinute float64
+	}{
+		{
+			name:      "files and duration",
+			s:         &SessionInfo{TotalTokens: 3000, SessionDurationSec: 90, FilesWritten: map[string]struct{}{"a.go": {}, "b.go": {}}},
+			perFile:   1500,
+			perMinute: 2000,
+		},
+		{
+			name:      "no files",
+			s:         &SessionInfo{TotalTokens: 3000, SessionDurationSec: 60},
+			perFile:   0,
+			perMinute: 3000,
+		},
+		{
+			name:    "zero duration",
+			s:       &SessionInfo{TotalTokens: 3000, FilesWritten: map[string]struct{}{"a.go": {}}},
+			perFile: 3000,
+		},
+		{
+			name: "zero tokens",
+			s:    &SessionInfo{SessionDurationSec: 60, FilesWritten: map[string]struct{}{"a.go": {}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.AvgTokensPerFile(); got != tt.perFile {
+				t.Errorf("AvgTokensPerFile: got %v, want %v", got, tt.perFile)
+			}
+			if got := tt.s.TokensPerMinute(); got != tt.perMinute {
+				t.Errorf("TokensPerMinute: got %v, want %v", got, tt.perMinute)
+			}
+		})
+	}
+}
+
+func TestCountPatchLines(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		added, removed int
+	}{
+		{"single hunk", "*** Begin Patch\n*** Update File: a.go\n@@ -1,2 +1,3 @@\n context\n-old\n+new\n+more\n*** End Patch", 2, 1},
+		{"file headers excluded", "*** Update File: a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-x\n+y\n", 1, 1},
+		{"lines outside hunks ignored", "+stray\n-stray\n*** Update File: a.go\n+before hunk\n@@\n+in hunk\n", 1, 0},
+		{"two files", "*** Update File: a.go\n@@\n+a\n*** Update File: b.go\n@@\n-b\n-c\n", 1, 2},
+		{"no hunks", "*** Begin Patch\n*** End Patch", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := countPatchLines(tt.input)
+			if added != tt.added || removed != tt.removed {
+				t.Errorf("got +%d -%d, want +%d -%d", added, removed, tt.added, tt.removed)
+			}
+		})
+	}
+}
+
+func TestDetectCodex_LinesChurn(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Patch churn is summed across sessions; the heredoc write adds nothing
+	session1 := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: a.go\n@@ -1,2 +1,3 @@\n-old\n+new\n+more\n*** End Patch"}}
+{"timestamp":"2026-02-10T10:26:10.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"cat > b.go <<'EOF'\\npackage b\\nEOF\"}"}}`
+	session2 := `{"timestamp":"2026-02-10T11:00:00.000Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T11:00:01.000Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: c.go\n@@ -3,2 +3 @@\n-x\n-y\n+z\n*** End Patch"}}`
+	for name, content := range map[string]string{
+		"rollout-2026-02-10T10-25-57-aaa.jsonl": session1,
+		"rollout-2026-02-10T11-00-00-bbb.jsonl": session2,
+	} {
+		if err := os.WriteFile(filepath.Join(sessionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if info.LinesAdded != 3 || info.LinesRemoved != 3 {
+		t.Errorf("churn: got +%d -%d, want +3 -3", info.LinesAdded, info.LinesRemoved)
+	}
+	if info.NetLineDelta != 0 {
+		t.Errorf("net delta: got %d, want 0", info.NetLineDelta)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"a.go", "b.go", "c.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+}
+
+func TestParseCodexSession_NetLineDelta(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: a.go\n@@ -1,2 +1,5 @@\n-old\n+new\n+one\n+two\n+three\n*** Update File: b.go\n@@ -1,4 +1 @@\n-w\n-x\n-y\n-z\n+z\n*** Update File: c.go\n@@ -9 +9,2 @@\n+tail\n*** End Patch"}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	// a.go +4 -1, b.go +1 -4, c.go +1
+	if info.LinesAdded != 6 || info.LinesRemoved != 5 {
+		t.Errorf("churn: got +%d -%d, want +6 -5", info.LinesAdded, info.LinesRemoved)
+	}
+	if info.NetLineDelta != 1 {
+		t.Errorf("net delta: got %d, want 1", info.NetLineDelta)
+	}
+}
+
+func TestExtractDirsFromCmd(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		want []string
+	}{
+		{"tar extract into dir", `tar -xzf template.tgz -C src/`, []string{"src"}},
+		{"tar dashless flags", `tar xzf template.tgz -C vendor/lib`, []string{"vendor/lib"}},
+		{"tar long directory", `tar --extract --file=a.tar --directory=out`, []string{"out"}},
+		{"tar create ignored", `tar -czf backup.tgz -C src .`, nil},
+		{"tar extract into cwd", `tar -xf a.tar`, nil},
+		{"unzip into dir", `unzip assets.zip -d public/`, []string{"public"}},
+		{"unzip into cwd", `unzip -o assets.zip`, nil},
+		{"rsync files-from equals", `rsync -a --files-from=list.txt src/ dst/`, []string{"dst"}},
+		{"rsync files-from separate", `rsync -av --files-from list.txt ./ build/out`, []string{"build/out"}},
+		{"rsync remote dest", `rsync -a --files-from=list.txt src/ host:/srv/`, nil},
+		{"rsync directory", `rsync -a a/ b/`, []string{"b"}},
+		{"rsync contents into dir", `rsync -av --exclude .git src/ dst`, []string{"dst"}},
+		{"rsync several sources", `rsync -a a.txt b.txt out`, []string{"out"}},
+		{"rsync file", `rsync a.txt b.txt`, nil},
+		{"rsync remote", `rsync -a src/ host:/srv/`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractDirsFromCmd(tt.cmd)
+			if !equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCodexSession_DirsWritten(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"tar -xzf template.tgz -C src/ && unzip assets.zip -d public\"}"}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info for extraction-only session")
+	}
+	if got := sortedKeys(info.DirsWritten); !equal(got, []string{"public", "src"}) {
+		t.Errorf("dirs: got %v", got)
+	}
+	if len(info.FilesWritten) != 0 {
+		t.Errorf("files: got %v, want none", sortedKeys(info.FilesWritten))
+	}
+}
+
+func TestParseCodexSession_RsyncFilesFrom(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"rsync -a --files-from=list.txt assets/ public/\"}"}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info for rsync session")
+	}
+	if got := sortedKeys(info.DirsWritten); !equal(got, []string{"public"}) {
+		t.Errorf("dirs: got %v, want [public]", got)
+	}
+	if got := sortedKeys(info.FilesRead); !equal(got, []string{"list.txt"}) {
+		t.Errorf("files read: got %v, want [list.txt]", got)
+	}
+}
+
+func TestDetectCodex_ModelAliases(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"timestamp":"2026-02-10T09:00:00.000Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T09:00:00.100Z","type":"turn_context","payload":{"model":"acme-fast"}}
+{"timestamp":"2026-02-10T09:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}
+{"timestamp":"2026-02-10T09:00:02.000Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":1000000,"output_tokens":0,"total_tokens":1000000}}}}`
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T09-00-00-aaa.jsonl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DetectorConfig{ModelAliases: map[string]string{"acme-fast": "gpt-5-mini"}}
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if info.Model != "gpt-5-mini" {
+		t.Errorf("model: got %q, want gpt-5-mini", info.Model)
+	}
+	if got := info.EstimatedCost(); got != 0.25 {
+		t.Errorf("cost: got %v, want 0.25", got)
+	}
+}
+
+func TestParseCodexSession_DdFileActions(t *testing.T) {
+	cwd := t.TempDir()
+	for _, name := range []string{"existing.img", "disk.img"} {
+		if err := os.WriteFile(filepath.Join(cwd, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content := `{"timestamp":"2026-02-10T10:25:00.000Z","type":"session_meta","payload":{"cwd":"` + cwd + `"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"dd if=patch.bin of=existing.img conv=notrunc,sync bs=512 seek=1\"}"}}
+{"timestamp":"2026-02-10T10:26:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"dd if=/dev/zero of=disk.img bs=1M count=1\"}"}}
+{"timestamp":"2026-02-10T10:26:02.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"dd if=/dev/zero of=fresh.bin bs=1M count=1 && dd if=x of=fresh.bin conv=notrunc\"}"}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+
+	want := map[string]FileAction{
+		"existing.img": FileActionModify,
+		"disk.img":     FileActionOverwrite,
+		"fresh.bin":    FileActionCreate,
+	}
+	for f, action := range want {
+		if got := info.FileActions[f]; got != action {
+			t.Errorf("action for %s: got %q, want %q", f, got, action)
+		}
+		if _, ok := info.FilesWritten[f]; !ok {
+			t.Errorf("%s missing from FilesWritten", f)
+		}
+	}
+}
+
+func TestDetectCodex_ResumedSession(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	original := `{"timestamp":"2026-02-10T09:00:00.000Z","type":"session_meta","payload":{"id":"sess-1","cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T09:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}
+{"timestamp":"2026-02-10T09:00:02.000Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1000},"last_token_usage":{"total_tokens":1000}}}}`
+	// The resume replays the original turn before continuing
+	resumed := `{"timestamp":"2026-02-10T11:00:00.000Z","type":"session_meta","payload":{"id":"sess-1","cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T11:00:00.100Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}
+{"timestamp":"2026-02-10T11:00:00.200Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1000},"last_token_usage":{"total_tokens":1000}}}}
+{"timestamp":"2026-02-10T11:00:30.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch b.go\"}"}}
+{"timestamp":"2026-02-10T11:01:00.000Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1500},"last_token_usage":{"total_tokens":500}}}}`
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T09-00-00-sess-1.jsonl"), []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T11-00-00-sess-1.jsonl"), []byte(resumed), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"a.go", "b.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+	if info.TotalTokens != 1500 {
+		t.Errorf("tokens: got %d, want 1500", info.TotalTokens)
+	}
+	if len(info.Turns) != 2 {
+		t.Errorf("turns: got %d, want 2 (replayed turn counted once)", len(info.Turns))
+	}
+	if info.SessionDurationSec != 60 {
+		t.Errorf("duration: got %d, want 60", info.SessionDurationSec)
+	}
+
+	parsed, err := parseCodexSession(context.Background(), filepath.Join(sessionDir, "rollout-2026-02-10T09-00-00-sess-1.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.SessionID != "sess-1" {
+		t.Errorf("session ID: got %q, want sess-1", parsed.SessionID)
+	}
+}
+
+func TestDetectForRepos(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rollout := func(name, cwd, cmd string) {
+		content := `{"timestamp":"2026-02-10T09:00:00.000Z","type":"session_meta","payload":{"cwd":"` + cwd + `"}}
+{"timestamp":"2026-02-10T09:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"` + cmd + `\"}"}}`
+		if err := os.WriteFile(filepath.Join(sessionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rollout("rollout-a1.jsonl", "/mono/api", "touch server.go")
+	rollout("rollout-a2.jsonl", "/mono/api", "touch handler.go")
+	rollout("rollout-b1.jsonl", "/mono/web", "touch app.ts")
+	rollout("rollout-c1.jsonl", "/elsewhere", "touch x.go")
+
+	got := DetectForRepos([]string{"/mono/api", "/mono/web", "/mono/docs"}, 72*time.Hour)
+
+	if len(got) != 2 {
+		t.Fatalf("expected sessions for 2 repos, got %d: %v", len(got), got)
+	}
+	if files := sortedKeys(got["/mono/api"].FilesWritten); !equal(files, []string{"handler.go", "server.go"}) {
+		t.Errorf("/mono/api files: got %v", files)
+	}
+	if files := sortedKeys(got["/mono/web"].FilesWritten); !equal(files, []string{"app.ts"}) {
+		t.Errorf("/mono/web files: got %v", files)
+	}
+	if _, ok := got["/mono/docs"]; ok {
+		t.Error("expected no entry for a repo without sessions")
+	}
+}
+
+func TestParseCodexSession_FlatTokenCount(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}
+{"timestamp":"2026-02-10T10:26:01.000Z","type":"event_msg","payload":{"type":"token_count","total_token_usage":{"input_tokens":800,"output_tokens":200,"total_tokens":1000},"last_token_usage":{"total_tokens":1000}}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if info.TotalTokens != 1000 || info.InputTokens != 800 || info.OutputTokens != 200 {
+		t.Errorf("tokens: got total=%d in=%d out=%d, want 1000/800/200", info.TotalTokens, info.InputTokens, info.OutputTokens)
+	}
+	if len(info.Turns) != 1 || info.Turns[0].Tokens != 1000 {
+		t.Errorf("turns: got %+v", info.Turns)
+	}
+}
+
+func TestCwdWithin(t *testing.T) {
+	tests := []struct {
+		cwd, root string
+		want      bool
+	}{
+		{"/Users/jose/myproject", "/Users/jose/myproject", true},
+		{"/Users/jose/myproject/backend", "/Users/jose/myproject", true},
+		{"/Users/jose/myproject/backend/api", "/Users/jose/myproject/", true},
+		{"/Users/jose/myproject2", "/Users/jose/myproject", false},
+		{"/Users/jose", "/Users/jose/myproject", false},
+		{"", "/Users/jose/myproject", false},
+	}
+	for _, tt := range tests {
+		if got := cwdWithin(tt.cwd, tt.root); got != tt.want {
+			t.Errorf("cwdWithin(%q, %q) = %v, want %v", tt.cwd, tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestCwdMatches_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "work", "project")
+	if err := os.MkdirAll(filepath.Join(repo, "backend"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	alias := filepath.Join(dir, "project")
+	if err := os.Symlink(repo, alias); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+
+	tests := []struct {
+		name         string
+		cwd, root    string
+		matchSubdirs bool
+		want         bool
+	}{
+		{"alias cwd", alias, repo, false, true},
+		{"alias root", repo, alias, false, true},
+		{"alias subdir", filepath.Join(alias, "backend"), repo, true, true},
+		{"alias subdir without MatchSubdirs", filepath.Join(alias, "backend"), repo, false, false},
+		{"missing cwd falls back to literal", filepath.Join(dir, "gone"), repo, false, false},
+		{"other dir", dir, repo, false, false},
+	}
+	for _, tt := range tests {
+		if got := cwdMatches(tt.cwd, tt.root, tt.matchSubdirs); got != tt.want {
+			t.Errorf("%s: cwdMatches(%q, %q) = %v, want %v", tt.name, tt.cwd, tt.root, got, tt.want)
+		}
+	}
+
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"` + alias + `"}}
+` + testCodexTouchLine
+	if !sessionMatches(t, writeTestJSONL(t, content), repo) {
+		t.Error("expected a session launched from the symlink to match the repo")
+	}
+}
+
+func TestDetectCodex_MatchSubdirs(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rollout := func(name, cwd, cmd string) {
+		content := `{"timestamp":"2026-02-10T09:00:00.000Z","type":"session_meta","payload":{"cwd":"` + cwd + `"}}
+{"timestamp":"2026-02-10T09:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"` + cmd + `\"}"}}`
+		if err := os.WriteFile(filepath.Join(sessionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rollout("rollout-root.jsonl", "/Users/jose/myproject", "touch main.go")
+	rollout("rollout-sub.jsonl", "/Users/jose/myproject/backend", "touch app.py")
+	rollout("rollout-sibling.jsonl", "/Users/jose/myproject2", "touch other.go")
+
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{MatchSubdirs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"backend/app.py", "main.go"}) {
+		t.Errorf("files: got %v, want [backend/app.py main.go]", got)
+	}
+
+	// Exact match by default
+	info, err = detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"main.go"}) {
+		t.Errorf("files with exact match: got %v, want [main.go]", got)
+	}
+}
+
+func TestExtractReadsFromCmd(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		want []string
+	}{
+		{"envsubst template", `envsubst < template.conf > out.conf`, []string{"template.conf"}},
+		{"rsync manifest", `rsync -a --files-from=list.txt src/ dst/`, []string{"list.txt"}},
+		{"plain redirect", `sort < in.txt > out.txt`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractReadsFromCmd(tt.cmd); !equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkDetectCodex measures detection over a sessions directory where
+// half the rollouts belong to other repos. Each rollout is opened once.
+func BenchmarkDetectCodex(b *testing.B) {
+	homeDir := b.TempDir()
+	b.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		b.Fatal(err)
+	}
+
+	var body strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&body, `{"timestamp":"2026-02-10T10:26:%02d.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch f%d.go\"}"}}`+"\n", i%60, i)
+		body.WriteString(`{"timestamp":"2026-02-10T10:26:00.000Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1000},"last_token_usage":{"total_tokens":5}}}}` + "\n")
+	}
+	for i := 0; i < 100; i++ {
+		for _, cwd := range []string{testRepoRoot, "/Users/jose/other"} {
+			content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"` + cwd + `"}}` + "\n" + body.String()
+			name := fmt.Sprintf("rollout-%03d-%s.jsonl", i, filepath.Base(cwd))
+			if err := os.WriteFile(filepath.Join(sessionDir, name), []byte(content), 0644); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// writeLargeRollout writes a rollout of roughly size bytes: session_meta and
+// turn_context first, filler writes to mid.go in the middle, and a final
+// write to end.go followed by the cumulative token_count.
+func writeLargeRollout(tb testing.TB, size int) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "rollout-large.jsonl")
+	f, err := os.Create(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, `{"timestamp":"2026-02-10T10:00:00.000Z","type":"session_meta","payload":{"cwd":"%s"}}`+"\n", testRepoRoot)
+	w.WriteString(`{"timestamp":"2026-02-10T10:00:00.100Z","type":"turn_context","payload":{"model":"gpt-5-codex"}}` + "\n")
+	filler := `{"timestamp":"2026-02-10T10:30:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch mid.go\"}"}}` + "\n"
+	for written := 0; written < size; written += len(filler) {
+		w.WriteString(filler)
+	}
+	w.WriteString(`{"timestamp":"2026-02-10T11:00:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch end.go\"}"}}` + "\n")
+	w.WriteString(`{"timestamp":"2026-02-10T11:00:01.000Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":9000,"output_tokens":1000,"total_tokens":10000}}}}` + "\n")
+	if err := w.Flush(); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestParseCodexSessionFor_MaxSessionBytes(t *testing.T) {
+	path := writeLargeRollout(t, 4*codexSampleBytes)
+
+	var warnings []string
+	cfg := DetectorConfig{
+		MaxSessionBytes: codexSampleBytes,
+		Warn:            func(msg string) { warnings = append(warnings, msg) },
+	}
+	info, err := parseCodexSessionFor(context.Background(), path, testRepoRoot, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info in sampling mode")
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "sampling its head and tail") {
+		t.Errorf("warnings: got %q", warnings)
+	}
+	if info.Model != "gpt-5-codex" {
+		t.Errorf("model: got %q", info.Model)
+	}
+	if info.TotalTokens != 10000 {
+		t.Errorf("tokens: got %d, want 10000", info.TotalTokens)
+	}
+	if info.SessionDurationSec != 3601 {
+		t.Errorf("duration: got %d, want 3601", info.SessionDurationSec)
+	}
+	if _, ok := info.FilesWritten["end.go"]; !ok {
+		t.Errorf("expected end.go from the sampled tail, got %v", sortedKeys(info.FilesWritten))
+	}
+}
+
+// BenchmarkParseCodexSession_Large parses a synthetic 100 MB rollout with a
+// 10 MB cap. Sampling keeps memory and time flat regardless of file size.
+func BenchmarkParseCodexSession_Large(b *testing.B) {
+	path := writeLargeRollout(b, 100<<20)
+	cfg := DetectorConfig{MaxSessionBytes: 10 << 20}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseCodexSessionFor(context.Background(), path, testRepoRoot, cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseCodexSession_GitStash(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"git stash\"}"}}
+{"timestamp":"2026-02-10T10:26:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}
+{"timestamp":"2026-02-10T10:26:02.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"git stash pop\"}"}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"a.go"}) {
+		t.Errorf("files: got %v, want [a.go]", got)
+	}
+	if !equal(info.GitActions, []string{"git stash", "git stash pop"}) {
+		t.Errorf("git actions: got %v", info.GitActions)
+	}
+
+	// A stash-only session writes nothing
+	path = writeTestJSONL(t, `{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"git stash\"}"}}`)
+	if info, err := parseCodexSession(context.Background(), path); err != nil || info != nil {
+		t.Errorf("stash-only session: got %+v, %v; want nil", info, err)
+	}
+}
+
+func TestDetectCodex_SessionErrors(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	// A directory named like a rollout can be opened but not read
+	badPath := filepath.Join(sessionDir, "rollout-2026-02-10T08-00-00-bad.jsonl")
+	if err := os.MkdirAll(badPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every session failed: the error is returned
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err == nil {
+		t.Fatalf("expected error when every session fails, got %+v", info)
+	}
+	var sessErr SessionError
+	if !errors.As(err, &sessErr) || sessErr.Path != badPath {
+		t.Errorf("error: got %v, want SessionError for %s", err, badPath)
+	}
+
+	// One good session: success, with the failure surfaced
+	good := `{"timestamp":"2026-02-10T09:00:00.000Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T09-00-00-good.jsonl"), []byte(good), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err = detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"a.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+	if len(info.Errors) != 1 || info.Errors[0].Path != badPath {
+		t.Errorf("errors: got %v, want one for %s", info.Errors, badPath)
+	}
+}
+
+func TestExtractHeredocWrites(t *testing.T) {
+	cmd := "cat > setup.sh <<'EOF'\ncat in.txt > out.txt\ncat <<INNER > nested.txt\ntouch skipped.txt\nINNER\nEOF\ntouch after.go"
+
+	// By default the body is content only; its redirects don't leak out
+	if got, want := extractFilesFromCmd(cmd), []string{"setup.sh", "after.go"}; !equal(got, want) {
+		t.Errorf("extractFilesFromCmd: got %v, want %v", got, want)
+	}
+
+	// Nested heredoc bodies are scanned too
+	got := extractHeredocWrites(cmd)
+	want := []string{"out.txt", "nested.txt", "skipped.txt"}
+	if !equal(got, want) {
+		t.Errorf("extractHeredocWrites: got %v, want %v", got, want)
+	}
+}
+
+func TestParseCodexSessionFor_HeredocWrites(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"cat > gen.sh <<'EOF'\\ncat tmpl > generated.txt\\nEOF\"}"}}`
+	path := writeTestJSONL(t, content)
+
+	tests := []struct {
+		name string
+		cfg  DetectorConfig
+		want []string
+	}{
+		{"default", DetectorConfig{}, []string{"gen.sh"}},
+		{"heredoc writes", DetectorConfig{HeredocWrites: true}, []string{"gen.sh", "generated.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := parseCodexSessionFor(context.Background(), path, testRepoRoot, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info == nil {
+				t.Fatal("expected non-nil info")
+			}
+			if got := sortedKeys(info.FilesWritten); !equal(got, tt.want) {
+				t.Errorf("files: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitHeredocs_SharedDelimiter(t *testing.T) {
+	cmd := "cat > a.txt <<EOF && cat > b.txt <<EOF\nfirst\nEOF\nsecond\nEOF\ntouch c.txt"
+	stripped, bodies := splitHeredocs(cmd)
+	if want := "cat > a.txt <<EOF && cat > b.txt <<EOF\ntouch c.txt"; stripped != want {
+		t.Errorf("stripped: got %q, want %q", stripped, want)
+	}
+	if want := []string{"first", "second"}; !equal(bodies, want) {
+		t.Errorf("bodies: got %q, want %q", bodies, want)
+	}
+}
+
+func TestCommandDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		workdir string
+		cmd     string
+		want    string
+	}{
+		{"no cd", "", "touch a.go", "/repo"},
+		{"leading cd", "", "cd backend && touch app.py", "/repo/backend"},
+		{"chained cds", "", "cd backend; cd app && touch x.py", "/repo/backend/app"},
+		{"cd up", "", "cd backend/.. && touch a.go", "/repo"},
+		{"absolute cd", "", "cd /tmp && touch a.go", "/tmp"},
+		{"workdir", "frontend", "touch a.ts", "/repo/frontend"},
+		{"workdir then cd", "frontend", "cd src && touch a.ts", "/repo/frontend/src"},
+		{"cd home stops", "", "cd ~ && touch a.go", "/repo"},
+		{"cd variable stops", "", "cd $DIR && touch a.go", "/repo"},
+		{"cd not leading", "", "touch a.go && cd backend", "/repo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commandDir("/repo", tt.workdir, tt.cmd); got != tt.want {
+				t.Errorf("commandDir(%q) = %q, want %q", tt.cmd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectCodex_ResolveCwd(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	session1 := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"cd backend && touch app.py\"}"}}
+{"timestamp":"2026-02-10T10:26:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch main.ts\",\"workdir\":\"frontend\"}"}}
+{"timestamp":"2026-02-10T10:26:02.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch ../shared/x.go\"}"}}`
+	session2 := `{"timestamp":"2026-02-10T11:00:00.000Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T11:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch ./backend/app.py /Users/jose/myproject/frontend/main.ts\"}"}}`
+	for name, content := range map[string]string{
+		"rollout-2026-02-10T10-25-57-aaa.jsonl": session1,
+		"rollout-2026-02-10T11-00-00-bbb.jsonl": session2,
+	} {
+		if err := os.WriteFile(filepath.Join(sessionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{ResolveCwd: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	want := []string{"/Users/jose/shared/x.go", "backend/app.py", "frontend/main.ts"}
+	if got := sortedKeys(info.FilesWritten); !equal(got, want) {
+		t.Errorf("files: got %v, want %v", got, want)
+	}
+
+	// Without the option paths are recorded as written
+	info, err = detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"../shared/x.go", "/Users/jose/myproject/frontend/main.ts", "app.py", "backend/app.py", "main.ts"}
+	if got := sortedKeys(info.FilesWritten); !equal(got, want) {
+		t.Errorf("files without ResolveCwd: got %v, want %v", got, want)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2026, 2, 10, 10, 25, 57, 0, time.UTC)
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"2026-02-10T10:25:57.000Z", want},
+		{"2026-02-10T10:25:57Z", want},
+		{"2026-02-10T12:25:57+02:00", want},
+		{"2026-02-10 10:25:57Z", want},
+		{"2026-02-10 10:25:57.5Z", want.Add(500 * time.Millisecond)},
+		{"2026-02-10 10:25:57", want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := parseTimestamp(tt.input)
+			if !ok || !got.Equal(tt.want) {
+				t.Errorf("parseTimestamp(%q) = %v, %v; want %v", tt.input, got, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := parseTimestamp("yesterday"); ok {
+		t.Error("expected unparseable timestamp to be rejected")
+	}
+}
+
+func TestParseCodexSession_MixedTimestamps(t *testing.T) {
+	content := `{"timestamp":"2026-02-10 10:00:00","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:01:00Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}
+{"timestamp":"2026-02-10 10:05:30.250Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch b.go\"}"}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if info.SessionDurationSec != 330 {
+		t.Errorf("duration: got %d, want 330", info.SessionDurationSec)
+	}
+	if want := time.Date(2026, 2, 10, 10, 0, 0, 0, time.UTC); !info.StartedAt.Equal(want) {
+		t.Errorf("started: got %v, want %v", info.StartedAt, want)
+	}
+}
+
+func TestDetectCodex_ExistingOnly(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	repoRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoRoot, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, "src", "kept.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	kept := filepath.Join(homeDir, "notes.txt")
+	if err := os.WriteFile(kept, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rollout := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"` + repoRoot + `"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch src/kept.go scratch.tmp ` + kept + ` /tmp/gone-scratch.txt\"}"}}`
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl"), []byte(rollout), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := detectCodex(context.Background(), repoRoot, 72*time.Hour, DetectorConfig{ExistingOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got, want := sortedKeys(info.FilesWritten), []string{kept, "src/kept.go"}; !equal(got, want) {
+		t.Errorf("files: got %v, want %v", got, want)
+	}
+
+	// Off by default: transient files are kept
+	info, err = detectCodex(context.Background(), repoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(info.FilesWritten); got != 4 {
+		t.Errorf("files without ExistingOnly: got %v", sortedKeys(info.FilesWritten))
+	}
+}
+
+func TestDetectCodex_Provider(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, provider := range map[string]string{
+		"rollout-2026-02-10T10-25-57-aaa.jsonl": "openai",
+		"rollout-2026-02-10T11-00-00-bbb.jsonl": "azure",
+	} {
+		content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject","model_provider":"` + provider + `"}}
+` + testCodexTouchLine
+		if err := os.WriteFile(filepath.Join(sessionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if info.Provider != "azure" {
+		t.Errorf("provider: got %q, want the latest session's %q", info.Provider, "azure")
+	}
+}
+
+func TestDetectCodex_Cancelled(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	info, err := detectCodex(ctx, testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if info != nil {
+		t.Errorf("expected nothing merged before cancellation, got %v", sortedKeys(info.FilesWritten))
+	}
+
+	path := filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl")
+	if _, err := parseCodexSession(ctx, path); !errors.Is(err, context.Canceled) {
+		t.Errorf("parseCodexSession: got error %v, want context.Canceled", err)
+	}
+}
+
+func TestDetectCodex_CancelledKeepsPartialMerge(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"rollout-a.jsonl", "rollout-b.jsonl"} {
+		content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch ` + name + `.go\"}"}}`
+		if err := os.WriteFile(filepath.Join(sessionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Cancel once the first session has been parsed: two checks while
+	// listing, then one before, one during and one after the first parse
+	ctx, cancel := cancelAfterErrChecks(5)
+	defer cancel()
+
+	info, err := detectCodex(ctx, testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+	if info == nil {
+		t.Fatal("expected the partial merge")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"rollout-a.jsonl.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+}
+
+// countingCtx is cancelled after its Err method has been called n times.
+type countingCtx struct {
+	context.Context
+	cancel context.CancelFunc
+	n      int
+}
+
+func (c *countingCtx) Err() error {
+	if c.n--; c.n < 0 {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
+
+func cancelAfterErrChecks(n int) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &countingCtx{Context: ctx, cancel: cancel, n: n}, cancel
+}
+
+func TestDetectCodex_Provenance(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pathA := filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl")
+	pathB := filepath.Join(sessionDir, "rollout-2026-02-10T11-00-00-bbb.jsonl")
+	for p, cmd := range map[string]string{
+		pathA: "touch a.go shared.go",
+		pathB: "touch shared.go",
+	} {
+		content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"` + cmd + `\"}"}}`
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{Provenance: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if !equal(info.SourceFiles, []string{pathA, pathB}) {
+		t.Errorf("source files: got %v", info.SourceFiles)
+	}
+	if got := info.FileSources["a.go"]; !equal(got, []string{pathA}) {
+		t.Errorf("a.go sources: got %v", got)
+	}
+	if got := info.FileSources["shared.go"]; !equal(got, []string{pathA, pathB}) {
+		t.Errorf("shared.go sources: got %v", got)
+	}
+
+	// Not recorded by default
+	info, err = detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.SourceFiles != nil || info.FileSources != nil {
+		t.Errorf("expected no provenance, got %v / %v", info.SourceFiles, info.FileSources)
+	}
+}
+
+func TestParseCodexSession_Events(t *testing.T) {
+	cwd := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cwd, "main.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"timestamp":"2026-02-10T10:00:00Z","type":"session_meta","payload":{"cwd":"` + cwd + `"}}
+{"timestamp":"2026-02-10T10:01:00Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch new.go\"}"}}
+{"timestamp":"2026-02-10T10:02:00Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: main.go\n*** End Patch"}}
+{"timestamp":"2026-02-10T10:03:00Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: main.go\n*** End Patch"}}
+{"timestamp":"2026-02-10T10:04:00Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: main.go\n*** End Patch"}}
+{"timestamp":"2026-02-10T10:05:00Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"rm -f new.go; npm rm left-pad\"}"}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	at := func(min int) time.Time { return time.Date(2026, 2, 10, 10, min, 0, 0, time.UTC) }
+	want := []FileEvent{
+		{Path: "new.go", Time: at(1), Kind: FileEventCreate},
+		{Path: "main.go", Time: at(2), Kind: FileEventWrite},
+		{Path: "new.go", Time: at(5), Kind: FileEventDelete},
+	}
+	if len(info.Events) != len(want) {
+		t.Fatalf("events: got %+v, want %+v", info.Events, want)
+	}
+	for i := range want {
+		got := info.Events[i]
+		if got.Path != want[i].Path || got.Kind != want[i].Kind || !got.Time.Equal(want[i].Time) {
+			t.Errorf("event %d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+	if len(info.FilesWritten) != 2 {
+		t.Errorf("files written: got %v, want main.go and new.go", sortedKeys(info.FilesWritten))
+	}
+}
+
+func TestParseVerbose(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:00:00Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:01:00Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go && go test ./...\"}"}}
+{"timestamp":"2026-02-10T10:02:00Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"ls -la\"}"}}
+{"timestamp":"2026-02-10T10:03:00Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: b.go\n*** End Patch"}}`
+
+	path := writeTestJSONL(t, content)
+	var buf bytes.Buffer
+	info, err := ParseVerbose(path, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"a.go", "b.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+
+	want := "line 2: exec_command: touch a.go && go test ./...\n" +
+		"  a.go <- " + fileWritePatterns[2].re.String() + "\n" +
+		"line 3: exec_command: ls -la\n" +
+		"  no match\n" +
+		"line 4: apply_patch\n" +
+		"  b.go <- " + applyPatchFilePattern.String() + "\n"
+	if buf.String() != want {
+		t.Errorf("log:\ngot:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestDetectCodex_Gzipped(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl.gz"), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{MaxSessionBytes: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if len(info.FilesWritten) == 0 {
+		t.Error("expected files from the gzipped rollout")
+	}
+}
+
+func TestParseCodexSessionFor_ExtensionFilters(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch main.go README.md NOTES.TXT Makefile\"}"}}`
+	path := writeTestJSONL(t, content)
+
+	tests := []struct {
+		name string
+		cfg  DetectorConfig
+		want []string
+	}{
+		{"no filters", DetectorConfig{}, []string{"Makefile", "NOTES.TXT", "README.md", "main.go"}},
+		{"exclude", DetectorConfig{ExcludeExts: []string{".md", "txt"}}, []string{"Makefile", "main.go"}},
+		{"include", DetectorConfig{IncludeExts: []string{".GO"}}, []string{"main.go"}},
+		{"exclude wins", DetectorConfig{IncludeExts: []string{"go", "md"}, ExcludeExts: []string{".MD"}}, []string{"main.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := parseCodexSessionFor(context.Background(), path, testRepoRoot, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info == nil {
+				t.Fatal("expected non-nil info")
+			}
+			if got := sortedKeys(info.FilesWritten); !equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunsTests(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{`go test ./...`, true},
+		{`cd pkg && go test -run TestX .`, true},
+		{`pytest -q tests/`, true},
+		{`python -m pytest`, true},
+		{`npm test`, true},
+		{`pnpm run test -- --watch=false`, true},
+		{`cargo test --all`, true},
+		{`go build ./...`, false},
+		{`npm install`, false},
+		{`cat <<'EOF' > notes.md
+run go test before merging
+EOF`, false},
+	}
+	for _, tt := range tests {
+		if got := runsTests(tt.cmd); got != tt.want {
+			t.Errorf("runsTests(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestParseCodexSession_RanTests(t *testing.T) {
+	content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine + `
+{"timestamp":"2026-02-10T10:27:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"go test ./...\"}"}}`
+
+	path := writeTestJSONL(t, content)
+	info, err := parseCodexSession(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if !info.RanTests {
+		t.Error("expected RanTests")
+	}
+}
+
+func TestDetectCodex_DirectoriesTouched(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, cmd := range map[string]string{
+		"rollout-2026-02-10T10-00-00-aaa.jsonl": "touch main.go cmd/root.go",
+		"rollout-2026-02-10T11-00-00-bbb.jsonl": "touch cmd/run.go internal/app/app.go",
+	} {
+		content := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"` + cmd + `\"}"}}`
+		if err := os.WriteFile(filepath.Join(sessionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	// ".", "cmd" and "internal/app" across both sessions
+	if info.DirectoriesTouched != 3 {
+		t.Errorf("directories: got %d, want 3 for %v", info.DirectoriesTouched, sortedKeys(info.FilesWritten))
+	}
 }
@@ -0,0 +1,313 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DetectorConfig holds optional behaviour switches for session detectors.
+// The zero value reproduces the default detection behaviour.
+type DetectorConfig struct {
+	// SameDayOnly restricts session merging to sessions that ended on the
+	// same calendar day. Only the most recent day's sessions are merged.
+	SameDayOnly bool
+
+	// Location is the timezone used to determine calendar days when
+	// SameDayOnly is set. Defaults to the local timezone.
+	Location *time.Location
+
+	// ModelAliases maps internal or renamed model names to canonical ones.
+	// Aliases are applied to parsed model names before they are stored on
+	// SessionInfo, so pricing lookups see the canonical name.
+	ModelAliases map[string]string
+
+	// RespectGitignore drops written files matched by the repo's .gitignore
+	// files (top-level and nested), such as node_modules/ or build output.
+	RespectGitignore bool
+
+	// AnonymizePaths replaces every path component with a stable hash,
+	// keeping extensions and directory depth, for sharing reports publicly.
+	// Anonymized paths no longer match committed files.
+	AnonymizePaths bool
+
+	// SkipGenerated drops written files that look vendored or generated,
+	// such as *.pb.go or anything under vendor/, using GeneratedPatterns.
+	SkipGenerated bool
+
+	// GeneratedPatterns replaces DefaultGeneratedPatterns for
+	// SkipGenerated; append to a copy of the defaults to extend them.
+	GeneratedPatterns []string
+
+	// MatchSubdirs also matches sessions whose working directory is inside
+	// the repo root, e.g. Codex launched from repo/backend. Their paths are
+	// rebased to be relative to the repo root. Defaults to exact cwd match.
+	MatchSubdirs bool
+
+	// MaxSessionBytes caps how much of a session file is parsed. Larger
+	// files are sampled (head and tail only) with a warning, recovering
+	// model and token totals but not every write. Zero means no limit.
+	MaxSessionBytes int64
+
+	// HeredocWrites also attributes files written by commands inside
+	// heredoc bodies, e.g. the redirects of a script generated with
+	// cat > setup.sh <<'EOF'. By default bodies are treated purely as
+	// content and never scanned.
+	HeredocWrites bool
+
+	// ResolveCwd resolves each command's paths against the directory it
+	// ran in (the session cwd, the call's workdir and any leading cd), so
+	// "cd backend && touch app.py" and "touch backend/app.py" both record
+	// backend/app.py. Paths are stored relative to the session cwd.
+	ResolveCwd bool
+
+	// ExistingOnly drops written files that no longer exist on disk, such
+	// as deleted scratch files. Relative paths are checked against the
+	// repo root, so combine it with MatchSubdirs or ResolveCwd when
+	// sessions ran in subdirectories.
+	ExistingOnly bool
+
+	// Provenance records which session files were merged (SourceFiles)
+	// and which of them wrote each file (FileSources), for debugging
+	// false positives. Off by default to avoid the bookkeeping.
+	Provenance bool
+
+	// MaxAge is how far back DetectAll looks for sessions. Defaults to
+	// 72h, or TEMPO_SESSION_MAX_AGE when set.
+	MaxAge time.Duration
+
+	// ToolMaxAge overrides MaxAge for individual tools, since tools prune
+	// their session histories at different rates.
+	ToolMaxAge map[Tool]time.Duration
+
+	// IncludeExts, when non-empty, limits the files recorded from Codex
+	// sessions to these extensions. ExcludeExts drops files with these
+	// extensions and takes precedence. Extensions match case-insensitively,
+	// with or without the leading dot.
+	IncludeExts []string
+	ExcludeExts []string
+
+	// SessionsDir is where Codex rollouts are read from. Defaults to the
+	// sessions directory of the Codex home, normally ~/.codex/sessions.
+	SessionsDir string
+
+	// ModelPrices overrides or extends the built-in price table used by
+	// EstimatedCost, keyed by model name prefix.
+	ModelPrices map[string]ModelPrice
+
+	// CacheSessions keeps Codex parse results in ~/.cache/tempo/sessions.json
+	// and reparses only rollouts whose modification time or size changed.
+	// Ignored while tracing.
+	CacheSessions bool
+
+	// Version is the running tempo version. A session cache written by
+	// another version is discarded, since its parsing rules may differ.
+	Version string
+
+	// Warn, when set, receives non-fatal problems met while detecting, such
+	// as an oversized rollout that was only sampled. They are dropped
+	// otherwise.
+	Warn func(msg string)
+
+	// trace, when set, receives a per-line log of the commands a Codex
+	// rollout ran and the paths extracted from them. See ParseVerbose.
+	trace io.Writer
+
+	// keepEmpty makes Codex rollout parsing return sessions that wrote
+	// nothing instead of nil. See parseCodexSessionRaw.
+	keepEmpty bool
+}
+
+// maxAgeFor returns the session window for tool: its ToolMaxAge override,
+// else MaxAge, else the default session max age.
+func (c DetectorConfig) maxAgeFor(tool Tool) time.Duration {
+	if d, ok := c.ToolMaxAge[tool]; ok && d > 0 {
+		return d
+	}
+	if c.MaxAge > 0 {
+		return c.MaxAge
+	}
+	return sessionMaxAge()
+}
+
+// codexSessionsDir returns SessionsDir, falling back to the Codex home's
+// sessions directory.
+func (c DetectorConfig) codexSessionsDir() string {
+	if c.SessionsDir != "" {
+		return c.SessionsDir
+	}
+	return defaultCodexSessionsDir()
+}
+
+// EstimatedCost is SessionInfo.EstimatedCost priced with ModelPrices
+// merged over the built-in table. An override replaces the built-in entry
+// for the same prefix; lookups still use the longest matching prefix.
+func (c DetectorConfig) EstimatedCost(s *SessionInfo) float64 {
+	if len(c.ModelPrices) == 0 {
+		return s.EstimatedCost()
+	}
+	prices := maps.Clone(modelPrices)
+	maps.Copy(prices, c.ModelPrices)
+	return s.estimatedCost(prices)
+}
+
+// generatedPatterns returns GeneratedPatterns, defaulting to
+// DefaultGeneratedPatterns.
+func (c DetectorConfig) generatedPatterns() []string {
+	if len(c.GeneratedPatterns) > 0 {
+		return c.GeneratedPatterns
+	}
+	return DefaultGeneratedPatterns
+}
+
+// extAllowed reports whether a written file passes IncludeExts and
+// ExcludeExts.
+func (c DetectorConfig) extAllowed(p string) bool {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(p), "."))
+	matches := func(exts []string) bool {
+		for _, e := range exts {
+			if strings.ToLower(strings.TrimPrefix(e, ".")) == ext {
+				return true
+			}
+		}
+		return false
+	}
+	if matches(c.ExcludeExts) {
+		return false
+	}
+	return len(c.IncludeExts) == 0 || matches(c.IncludeExts)
+}
+
+// DetectOptions narrows what Detect looks at. The zero value runs every
+// detector over the default session window.
+type DetectOptions struct {
+	// Since is how far back sessions are considered. Defaults to 72h, or
+	// TEMPO_SESSION_MAX_AGE when set.
+	Since time.Duration
+
+	// Tools limits detection to the listed tools. Empty means all tools.
+	Tools []Tool
+
+	// NoCache reparses every session file instead of using the parsed
+	// session cache, which Detect otherwise uses. See
+	// DetectorConfig.CacheSessions.
+	NoCache bool
+
+	// Version is the running tempo version, recorded in the session cache.
+	Version string
+
+	// Warn receives non-fatal detection problems. See DetectorConfig.Warn.
+	Warn func(msg string)
+}
+
+// detectorConfig returns the DetectorConfig Detect runs with: the settings
+// from LoadConfig, with the options set in o taking precedence.
+func (o DetectOptions) detectorConfig() (DetectorConfig, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return DetectorConfig{}, err
+	}
+	if o.Since > 0 {
+		cfg.MaxAge = o.Since
+	}
+	cfg.CacheSessions = !o.NoCache
+	cfg.Version = o.Version
+	cfg.Warn = o.Warn
+	return cfg, nil
+}
+
+// includes reports whether tool is selected by o.Tools.
+func (o DetectOptions) includes(tool Tool) bool {
+	if len(o.Tools) == 0 {
+		return true
+	}
+	for _, t := range o.Tools {
+		if t == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// location returns the configured timezone, falling back to local time.
+func (c DetectorConfig) location() *time.Location {
+	if c.Location != nil {
+		return c.Location
+	}
+	return time.Local
+}
+
+// warnf passes a formatted warning to c.Warn, if set.
+func (c DetectorConfig) warnf(format string, args ...any) {
+	if c.Warn != nil {
+		c.Warn(fmt.Sprintf(format, args...))
+	}
+}
+
+// normalizeModel resolves model through ModelAliases, returning it unchanged
+// when no alias is configured.
+func (c DetectorConfig) normalizeModel(model string) string {
+	if alias, ok := c.ModelAliases[model]; ok {
+		return alias
+	}
+	return model
+}
+
+// fileConfig is the on-disk form of DetectorConfig read by LoadConfig.
+type fileConfig struct {
+	SessionsDir string                `json:"sessions_dir"`
+	MaxAge      string                `json:"max_age"`
+	IncludeExts []string              `json:"include_exts"`
+	ExcludeExts []string              `json:"exclude_exts"`
+	ModelPrices map[string]ModelPrice `json:"model_prices"`
+}
+
+// detectorConfigPath returns TEMPO_CONFIG when set, else
+// ~/.config/tempo/config.json.
+func detectorConfigPath() string {
+	if p := os.Getenv("TEMPO_CONFIG"); p != "" {
+		return p
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "tempo", "config.json")
+}
+
+// LoadConfig reads detector settings from ~/.config/tempo/config.json, or
+// the file named by TEMPO_CONFIG. Fields missing from the file keep their
+// defaults, and a missing file yields the default config. max_age is a Go
+// duration such as "48h".
+func LoadConfig() (DetectorConfig, error) {
+	cfgPath := detectorConfigPath()
+	data, err := os.ReadFile(cfgPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DetectorConfig{}, nil
+		}
+		return DetectorConfig{}, err
+	}
+	var fc fileConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return DetectorConfig{}, fmt.Errorf("parsing %s: %w", cfgPath, err)
+	}
+
+	cfg := DetectorConfig{
+		SessionsDir: fc.SessionsDir,
+		IncludeExts: fc.IncludeExts,
+		ExcludeExts: fc.ExcludeExts,
+		ModelPrices: fc.ModelPrices,
+	}
+	if fc.MaxAge != "" {
+		d, err := time.ParseDuration(fc.MaxAge)
+		if err != nil || d <= 0 {
+			return DetectorConfig{}, fmt.Errorf("parsing %s: invalid max_age %q", cfgPath, fc.MaxAge)
+		}
+		cfg.MaxAge = d
+	}
+	return cfg, nil
+}
@@ -0,0 +1,73 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("TEMPO_CONFIG", "")
+	t.Setenv("TEMPO_SESSION_MAX_AGE", "")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("missing config file: %v", err)
+	}
+	if got, want := cfg.codexSessionsDir(), filepath.Join(homeDir, ".codex", "sessions"); got != want {
+		t.Errorf("sessions dir: got %q, want %q", got, want)
+	}
+	if got := cfg.maxAgeFor(ToolCodex); got != 72*time.Hour {
+		t.Errorf("max age: got %v, want 72h", got)
+	}
+	if !cfg.extAllowed("main.go") {
+		t.Error("expected every extension to be allowed by default")
+	}
+	s := &SessionInfo{Model: "gpt-5", InputTokens: 1_000_000}
+	if got := cfg.EstimatedCost(s); got != 1.25 {
+		t.Errorf("cost: got %v, want the built-in price 1.25", got)
+	}
+}
+
+func TestLoadConfig_Partial(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "tempo.json")
+	t.Setenv("TEMPO_CONFIG", path)
+	data := `{"max_age": "48h", "exclude_exts": [".md"], "model_prices": {"gpt-5": {"input_per_mtok": 2, "output_per_mtok": 20}}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxAge != 48*time.Hour {
+		t.Errorf("max age: got %v, want 48h", cfg.MaxAge)
+	}
+	if cfg.extAllowed("README.md") || !cfg.extAllowed("main.go") {
+		t.Errorf("exclude_exts not applied: %v", cfg.ExcludeExts)
+	}
+	if cfg.SessionsDir != "" || len(cfg.IncludeExts) != 0 {
+		t.Errorf("unset fields should keep defaults: %+v", cfg)
+	}
+
+	s := &SessionInfo{Model: "gpt-5-codex", InputTokens: 1_000_000, OutputTokens: 1_000_000}
+	if got := cfg.EstimatedCost(s); got != 22 {
+		t.Errorf("overridden cost: got %v, want 22", got)
+	}
+	s.Model = "o3"
+	if got := cfg.EstimatedCost(s); got != 10 {
+		t.Errorf("built-in cost: got %v, want 10", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"max_age": "two days"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(); err == nil {
+		t.Error("expected an error for an invalid max_age")
+	}
+}
@@ -31,10 +31,10 @@ type copilotSession struct {
 }
 
 type copilotRequest struct {
-	Timestamp int64              `json:"timestamp"` // unix ms
-	ModelID   string             `json:"modelId"`
-	Agent     *copilotAgent      `json:"agent"`
-	Response  []copilotRespPart  `json:"response"`
+	Timestamp int64             `json:"timestamp"` // unix ms
+	ModelID   string            `json:"modelId"`
+	Agent     *copilotAgent     `json:"agent"`
+	Response  []copilotRespPart `json:"response"`
 }
 
 type copilotAgent struct {
@@ -93,7 +93,13 @@ func vscodeBaseDirs() []string {
 // findCopilotWorkspace finds the VS Code workspace storage directory
 // whose workspace.json maps to the given repo root.
 func findCopilotWorkspace(repoRoot string) string {
-	for _, baseDir := range vscodeBaseDirs() {
+	return findWorkspaceDir(vscodeBaseDirs(), repoRoot)
+}
+
+// findWorkspaceDir scans VS Code-style workspaceStorage base directories and
+// returns the {hash} directory whose workspace.json folder maps to repoRoot.
+func findWorkspaceDir(baseDirs []string, repoRoot string) string {
+	for _, baseDir := range baseDirs {
 		entries, err := os.ReadDir(baseDir)
 		if err != nil {
 			continue
@@ -221,47 +227,33 @@ func parseCopilotSession(jsonPath string, repoRoot string) (*SessionInfo, error)
 
 	if firstTimestamp > 0 && lastTimestamp > 0 {
 		info.SessionDurationSec = (lastTimestamp - firstTimestamp) / 1000
+		info.StartedAt = time.UnixMilli(firstTimestamp)
+		info.EndedAt = time.UnixMilli(lastTimestamp)
 	}
 
 	return info, nil
 }
 
-// detectCopilot finds recent Copilot Agent sessions for the repo
-// and merges their file sets.
+// detectCopilot finds recent Copilot Agent sessions for the repo and merges
+// them. Chat sessions are independent, so their durations are summed.
 func detectCopilot(repoRoot string, maxAge time.Duration) (*SessionInfo, error) {
 	workspaceDir := findCopilotWorkspace(repoRoot)
 	if workspaceDir == "" {
 		return nil, nil
 	}
 
-	sessions, err := findCopilotSessions(workspaceDir, maxAge)
-	if err != nil || len(sessions) == 0 {
+	paths, err := findCopilotSessions(workspaceDir, maxAge)
+	if err != nil || len(paths) == 0 {
 		return nil, nil
 	}
 
-	merged := &SessionInfo{
-		Tool:         ToolCopilot,
-		FilesWritten: make(map[string]struct{}),
-	}
-
-	for _, path := range sessions {
+	var sessions []*SessionInfo
+	for _, path := range paths {
 		session, err := parseCopilotSession(path, repoRoot)
 		if err != nil || session == nil {
 			continue
 		}
-		for f := range session.FilesWritten {
-			merged.FilesWritten[f] = struct{}{}
-		}
-		if session.Model != "" {
-			merged.Model = session.Model
-		}
-		if session.SessionDurationSec > merged.SessionDurationSec {
-			merged.SessionDurationSec = session.SessionDurationSec
-		}
-	}
-
-	if len(merged.FilesWritten) == 0 {
-		return nil, nil
+		sessions = append(sessions, session)
 	}
-	return merged, nil
+	return mergeSessions(sessions, usageSum), nil
 }
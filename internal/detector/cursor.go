@@ -3,7 +3,6 @@ package detector
 import (
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -178,45 +177,7 @@ func cursorGlobalDBPath() string {
 // findCursorWorkspace finds the Cursor workspace storage directory
 // whose workspace.json maps to the given repo root.
 func findCursorWorkspace(repoRoot string) string {
-	for _, baseDir := range cursorBaseDirs() {
-		entries, err := os.ReadDir(baseDir)
-		if err != nil {
-			continue
-		}
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				continue
-			}
-			wsPath := filepath.Join(baseDir, entry.Name(), "workspace.json")
-			data, err := os.ReadFile(wsPath)
-			if err != nil {
-				continue
-			}
-			var ws struct {
-				Folder string `json:"folder"`
-			}
-			if err := json.Unmarshal(data, &ws); err != nil {
-				continue
-			}
-			folder := cursorURIToPath(ws.Folder)
-			if folder == repoRoot {
-				return filepath.Join(baseDir, entry.Name())
-			}
-		}
-	}
-	return ""
-}
-
-// cursorURIToPath converts a file:// URI to a local path.
-func cursorURIToPath(uri string) string {
-	if !strings.HasPrefix(uri, "file://") {
-		return uri
-	}
-	u, err := url.Parse(uri)
-	if err != nil {
-		return strings.TrimPrefix(uri, "file://")
-	}
-	return u.Path
+	return findWorkspaceDir(cursorBaseDirs(), repoRoot)
 }
 
 // findCursorComposers reads the workspace state.vscdb and returns recent
@@ -295,6 +256,8 @@ func parseCursorBubbles(globalDBPath string, composerIds []string) (*SessionInfo
 			// Sum token counts
 			if bubble.TokenCount != nil {
 				info.TotalTokens += bubble.TokenCount.InputTokens + bubble.TokenCount.OutputTokens
+				info.InputTokens += bubble.TokenCount.InputTokens
+				info.OutputTokens += bubble.TokenCount.OutputTokens
 			}
 
 			if bubble.ToolFormerData == nil {
@@ -1,12 +1,14 @@
 package detector
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,8 +29,104 @@ func sessionMaxAge() time.Duration {
 	return defaultMaxAgeHours * time.Hour
 }
 
-// Detect runs the full detection pipeline for the current HEAD commit.
-func Detect(repoRoot string) (*Attribution, error) {
+// sessionDetector is a registered session-log detector.
+type sessionDetector struct {
+	tool   Tool
+	detect func(repoRoot string, maxAge time.Duration, cfg DetectorConfig) (*SessionInfo, error)
+}
+
+// sessionDetectors lists the session-log detectors in the order their
+// detections are reported, guarded by detectorsMu.
+var (
+	detectorsMu      sync.RWMutex
+	sessionDetectors = []sessionDetector{
+		{ToolClaudeCode, ignoreConfig(detectClaudeCode)},
+		{ToolAider, ignoreConfig(detectAider)},
+		{ToolCodex, func(repoRoot string, maxAge time.Duration, cfg DetectorConfig) (*SessionInfo, error) {
+			return detectCodex(context.Background(), repoRoot, maxAge, cfg)
+		}},
+		{ToolCopilot, ignoreConfig(detectCopilot)},
+		{ToolCursor, ignoreConfig(detectCursor)},
+		{ToolWindsurf, ignoreConfig(detectWindsurf)},
+		{ToolCline, ignoreConfig(detectCline)},
+		{ToolZed, ignoreConfig(detectZed)},
+		{ToolAmazonQ, ignoreConfig(detectAmazonQ)},
+		{ToolJunie, ignoreConfig(detectJunie)},
+	}
+)
+
+// RegisterDetector adds a session detector for tool, so tools outside this
+// package are picked up by DetectAll and Detect. Registering a tool that
+// already has a detector replaces it; new tools are run after the built-in
+// ones. fn follows the built-in detectors' contract: return nil when no
+// recent session wrote files in repoRoot.
+func RegisterDetector(tool Tool, fn func(repoRoot string, maxAge time.Duration) (*SessionInfo, error)) {
+	detectorsMu.Lock()
+	defer detectorsMu.Unlock()
+	d := sessionDetector{tool: tool, detect: ignoreConfig(fn)}
+	for i := range sessionDetectors {
+		if sessionDetectors[i].tool == tool {
+			sessionDetectors[i] = d
+			return
+		}
+	}
+	sessionDetectors = append(sessionDetectors, d)
+}
+
+// registeredDetectors returns a snapshot of the session detectors.
+func registeredDetectors() []sessionDetector {
+	detectorsMu.RLock()
+	defer detectorsMu.RUnlock()
+	return append([]sessionDetector(nil), sessionDetectors...)
+}
+
+// ParseTool returns the tool named s, ignoring case, for command-line
+// flags such as --tool codex. Tools added with RegisterDetector are
+// recognized too. Unknown names are an error.
+func ParseTool(s string) (Tool, error) {
+	name := strings.TrimSpace(s)
+	for _, d := range registeredDetectors() {
+		if strings.EqualFold(string(d.tool), name) {
+			return d.tool, nil
+		}
+	}
+	return "", fmt.Errorf("unknown tool %q", s)
+}
+
+// ignoreConfig adapts a detector that takes no DetectorConfig.
+func ignoreConfig(detect func(string, time.Duration) (*SessionInfo, error)) func(string, time.Duration, DetectorConfig) (*SessionInfo, error) {
+	return func(repoRoot string, maxAge time.Duration, _ DetectorConfig) (*SessionInfo, error) {
+		return detect(repoRoot, maxAge)
+	}
+}
+
+// DetectAll runs every session detector for repoRoot and returns the
+// sessions found, keyed by tool. Each tool looks back over
+// cfg.maxAgeFor(tool). Detectors that fail or find nothing are omitted.
+func DetectAll(repoRoot string, cfg DetectorConfig) map[Tool]*SessionInfo {
+	return detectSessions(repoRoot, cfg, func(Tool) bool { return true })
+}
+
+// detectSessions runs the session detectors for the tools include accepts.
+func detectSessions(repoRoot string, cfg DetectorConfig, include func(Tool) bool) map[Tool]*SessionInfo {
+	sessions := make(map[Tool]*SessionInfo)
+	for _, d := range registeredDetectors() {
+		if !include(d.tool) {
+			continue
+		}
+		session, err := d.detect(repoRoot, cfg.maxAgeFor(d.tool), cfg)
+		if err == nil && session != nil {
+			sessions[d.tool] = session
+		}
+	}
+	return sessions
+}
+
+// Detect runs the full detection pipeline for the current HEAD commit,
+// restricted to opts.Tools and to sessions within opts.Since. Detector
+// settings are read with LoadConfig; opts.Since overrides its max_age.
+// Parsed Codex sessions are cached between runs unless opts.NoCache is set.
+func Detect(repoRoot string, opts DetectOptions) (*Attribution, error) {
 	committedFiles, err := getCommittedFiles(repoRoot)
 	if err != nil {
 		return nil, fmt.Errorf("getting committed files: %w", err)
@@ -36,6 +134,10 @@ func Detect(repoRoot string) (*Attribution, error) {
 	if len(committedFiles) == 0 {
 		return nil, nil
 	}
+	cfg, err := opts.detectorConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
 
 	commitSHA, _ := gitOutput(repoRoot, "rev-parse", "HEAD")
 	commitAuthor, _ := gitOutput(repoRoot, "log", "-1", "--format=%ae")
@@ -50,90 +152,20 @@ func Detect(repoRoot string) (*Attribution, error) {
 	}
 
 	committedSet := toSet(committedFiles)
-	maxAge := sessionMaxAge()
+	sessions := detectSessions(repoRoot, cfg, opts.includes)
 
 	// Strategy 1: File matching (HIGH confidence)
 	fileMatchDetected := make(map[Tool]bool)
-
-	// Claude Code
-	if session, err := detectClaudeCode(repoRoot, maxAge); err == nil && session != nil {
-		matched := intersect(session.FilesWritten, committedSet)
-		if len(matched) > 0 {
-			fileMatchDetected[ToolClaudeCode] = true
-			attr.Detections = append(attr.Detections, Detection{
-				Tool:               ToolClaudeCode,
-				Confidence:         ConfidenceHigh,
-				Method:             MethodFileMatch,
-				FilesMatched:       matched,
-				FilesCommitted:     len(committedFiles),
-				AIFiles:            len(matched),
-				Model:              session.Model,
-				TokenUsage:         session.TotalTokens,
-				SessionDurationSec: session.SessionDurationSec,
-			})
-		}
-	}
-
-	// Aider
-	if session, err := detectAider(repoRoot, maxAge); err == nil && session != nil {
-		matched := intersect(session.FilesWritten, committedSet)
-		if len(matched) > 0 {
-			fileMatchDetected[ToolAider] = true
-			attr.Detections = append(attr.Detections, Detection{
-				Tool:           ToolAider,
-				Confidence:     ConfidenceHigh,
-				Method:         MethodFileMatch,
-				FilesMatched:   matched,
-				FilesCommitted: len(committedFiles),
-				AIFiles:        len(matched),
-			})
-		}
-	}
-
-	// Codex
-	if session, err := detectCodex(repoRoot, maxAge); err == nil && session != nil {
-		matched := intersect(session.FilesWritten, committedSet)
-		if len(matched) > 0 {
-			fileMatchDetected[ToolCodex] = true
-			attr.Detections = append(attr.Detections, Detection{
-				Tool:               ToolCodex,
-				Confidence:         ConfidenceHigh,
-				Method:             MethodFileMatch,
-				FilesMatched:       matched,
-				FilesCommitted:     len(committedFiles),
-				AIFiles:            len(matched),
-				Model:              session.Model,
-				TokenUsage:         session.TotalTokens,
-				SessionDurationSec: session.SessionDurationSec,
-			})
-		}
-	}
-
-	// Copilot Agent
-	if session, err := detectCopilot(repoRoot, maxAge); err == nil && session != nil {
-		matched := intersect(session.FilesWritten, committedSet)
-		if len(matched) > 0 {
-			fileMatchDetected[ToolCopilot] = true
-			attr.Detections = append(attr.Detections, Detection{
-				Tool:               ToolCopilot,
-				Confidence:         ConfidenceHigh,
-				Method:             MethodFileMatch,
-				FilesMatched:       matched,
-				FilesCommitted:     len(committedFiles),
-				AIFiles:            len(matched),
-				Model:              session.Model,
-				SessionDurationSec: session.SessionDurationSec,
-			})
+	for _, d := range registeredDetectors() {
+		session := sessions[d.tool]
+		if session == nil {
+			continue
 		}
-	}
-
-	// Cursor Agent
-	if session, err := detectCursor(repoRoot, maxAge); err == nil && session != nil {
 		matched := intersect(session.FilesWritten, committedSet)
 		if len(matched) > 0 {
-			fileMatchDetected[ToolCursor] = true
+			fileMatchDetected[d.tool] = true
 			attr.Detections = append(attr.Detections, Detection{
-				Tool:               ToolCursor,
+				Tool:               d.tool,
 				Confidence:         ConfidenceHigh,
 				Method:             MethodFileMatch,
 				FilesMatched:       matched,
@@ -148,7 +180,7 @@ func Detect(repoRoot string) (*Attribution, error) {
 
 	// Strategy 2: Process detection (MEDIUM confidence)
 	for _, tool := range detectProcesses() {
-		if !fileMatchDetected[tool] {
+		if !fileMatchDetected[tool] && opts.includes(tool) {
 			attr.Detections = append(attr.Detections, Detection{
 				Tool:           tool,
 				Confidence:     ConfidenceMedium,
@@ -164,7 +196,7 @@ func Detect(repoRoot string) (*Attribution, error) {
 		alreadyDetected[d.Tool] = true
 	}
 	for _, d := range detectTrailers(commitMsg) {
-		if !alreadyDetected[d.Tool] {
+		if !alreadyDetected[d.Tool] && opts.includes(d.Tool) {
 			d.FilesCommitted = len(committedFiles)
 			attr.Detections = append(attr.Detections, d)
 		}
@@ -187,30 +219,15 @@ func detectClaudeCode(repoRoot string, maxAge time.Duration) (*SessionInfo, erro
 		return nil, err
 	}
 
-	merged := &SessionInfo{
-		Tool:         ToolClaudeCode,
-		FilesWritten: make(map[string]struct{}),
-	}
-
+	var sessions []*SessionInfo
 	for _, p := range paths {
 		info, err := parseClaudeSession(p, repoRoot)
 		if err != nil || info == nil {
 			continue
 		}
-		for f := range info.FilesWritten {
-			merged.FilesWritten[f] = struct{}{}
-		}
-		if info.Model != "" {
-			merged.Model = info.Model
-		}
-		merged.TotalTokens += info.TotalTokens
-		merged.SessionDurationSec += info.SessionDurationSec
-	}
-
-	if len(merged.FilesWritten) == 0 {
-		return nil, nil
+		sessions = append(sessions, info)
 	}
-	return merged, nil
+	return mergeSessions(sessions, usageSum), nil
 }
 
 func getCommittedFiles(repoRoot string) ([]string, error) {
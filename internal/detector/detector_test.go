@@ -1,7 +1,13 @@
 package detector
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestIntersect(t *testing.T) {
@@ -115,3 +121,258 @@ func TestSessionMaxAge_Invalid(t *testing.T) {
 		t.Errorf("got %v, want 72h default on invalid input", got)
 	}
 }
+
+func TestDetectAll_ToolMaxAge(t *testing.T) {
+	t.Setenv("TEMPO_SESSION_MAX_AGE", "")
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	repoRoot := t.TempDir()
+	old := time.Now().Add(-5 * 24 * time.Hour)
+
+	// Aider history and Codex rollout, both last written 5 days ago
+	historyPath := filepath.Join(repoRoot, ".aider.chat.history.md")
+	if err := os.WriteFile(historyPath, []byte(testAiderHistory), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(historyPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rollout := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"` + repoRoot + `"}}
+{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch a.go\"}"}}`
+	rolloutPath := filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl")
+	if err := os.WriteFile(rolloutPath, []byte(rollout), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(rolloutPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		cfg       DetectorConfig
+		wantAider bool
+		wantCodex bool
+	}{
+		{"default window", DetectorConfig{}, false, false},
+		{"aider override", DetectorConfig{
+			ToolMaxAge: map[Tool]time.Duration{ToolAider: 7 * 24 * time.Hour},
+		}, true, false},
+		{"global window with codex override", DetectorConfig{
+			MaxAge:     7 * 24 * time.Hour,
+			ToolMaxAge: map[Tool]time.Duration{ToolCodex: 24 * time.Hour},
+		}, true, false},
+		{"both overridden", DetectorConfig{
+			ToolMaxAge: map[Tool]time.Duration{
+				ToolAider: 7 * 24 * time.Hour,
+				ToolCodex: 7 * 24 * time.Hour,
+			},
+		}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectAll(repoRoot, tt.cfg)
+			if (got[ToolAider] != nil) != tt.wantAider {
+				t.Errorf("aider detected: got %v, want %v", got[ToolAider] != nil, tt.wantAider)
+			}
+			if (got[ToolCodex] != nil) != tt.wantCodex {
+				t.Errorf("codex detected: got %v, want %v", got[ToolCodex] != nil, tt.wantCodex)
+			}
+		})
+	}
+}
+
+func TestDetectOptions(t *testing.T) {
+	t.Setenv("TEMPO_SESSION_MAX_AGE", "")
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	repoRoot := t.TempDir()
+
+	// Aider history written now, Codex rollout written 5 hours ago
+	historyPath := filepath.Join(repoRoot, ".aider.chat.history.md")
+	if err := os.WriteFile(historyPath, []byte(testAiderHistory), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rollout := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"` + repoRoot + `"}}
+` + testCodexTouchLine
+	rolloutPath := filepath.Join(sessionDir, "rollout-2026-02-10T10-25-57-aaa.jsonl")
+	if err := os.WriteFile(rolloutPath, []byte(rollout), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-5 * time.Hour)
+	if err := os.Chtimes(rolloutPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts DetectOptions
+		want []Tool
+	}{
+		{"all tools", DetectOptions{}, []Tool{ToolAider, ToolCodex}},
+		{"codex only", DetectOptions{Tools: []Tool{ToolCodex}}, []Tool{ToolCodex}},
+		{"last hour", DetectOptions{Since: time.Hour}, []Tool{ToolAider}},
+		{"codex in last 6 hours", DetectOptions{Since: 6 * time.Hour, Tools: []Tool{ToolCodex}}, []Tool{ToolCodex}},
+		{"codex in last hour", DetectOptions{Since: time.Hour, Tools: []Tool{ToolCodex}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectSessions(repoRoot, DetectorConfig{MaxAge: tt.opts.Since}, tt.opts.includes)
+			var tools []string
+			for tool := range got {
+				tools = append(tools, string(tool))
+			}
+			sort.Strings(tools)
+			var want []string
+			for _, tool := range tt.want {
+				want = append(want, string(tool))
+			}
+			if !equal(tools, want) {
+				t.Errorf("got %v, want %v", tools, want)
+			}
+		})
+	}
+}
+
+func TestDetect_LoadsConfig(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	t.Setenv("TEMPO_SESSION_MAX_AGE", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CODEX_HOME", "")
+	repoRoot := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = repoRoot
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "init")
+	if err := os.WriteFile(filepath.Join(repoRoot, "a.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "a.go")
+	git("commit", "-q", "-m", "add a.go")
+
+	// A Codex rollout outside the default sessions directory, 5 hours old
+	sessionsDir := t.TempDir()
+	rollout := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"` + repoRoot + `"}}
+` + testCodexTouchLine
+	rolloutPath := filepath.Join(sessionsDir, "rollout-2026-02-10T10-25-57-aaa.jsonl")
+	if err := os.WriteFile(rolloutPath, []byte(rollout), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-5 * time.Hour)
+	if err := os.Chtimes(rolloutPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("TEMPO_CONFIG", configPath)
+	writeConfig := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	codexMatched := func(opts DetectOptions) bool {
+		t.Helper()
+		opts.NoCache = true
+		attr, err := Detect(repoRoot, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if attr == nil {
+			return false
+		}
+		for _, d := range attr.Detections {
+			if d.Tool == ToolCodex && d.Method == MethodFileMatch {
+				return true
+			}
+		}
+		return false
+	}
+
+	writeConfig(`{"sessions_dir": "` + sessionsDir + `", "max_age": "6h"}`)
+	if !codexMatched(DetectOptions{}) {
+		t.Error("expected the session from the configured sessions_dir to match")
+	}
+	if codexMatched(DetectOptions{Since: time.Hour}) {
+		t.Error("expected Since to take precedence over max_age")
+	}
+
+	writeConfig(`{"sessions_dir": "` + sessionsDir + `", "max_age": "6h", "exclude_exts": [".go"]}`)
+	if codexMatched(DetectOptions{}) {
+		t.Error("expected exclude_exts to drop a.go")
+	}
+
+	writeConfig(`{"max_age": "soon"}`)
+	if _, err := Detect(repoRoot, DetectOptions{}); err == nil {
+		t.Error("expected an error for an invalid config")
+	}
+}
+
+func TestRegisterDetector(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	saved := registeredDetectors()
+	t.Cleanup(func() { sessionDetectors = saved })
+
+	const toolFake Tool = "fake-agent"
+	var gotRoot string
+	var gotMaxAge time.Duration
+	RegisterDetector(toolFake, func(repoRoot string, maxAge time.Duration) (*SessionInfo, error) {
+		gotRoot, gotMaxAge = repoRoot, maxAge
+		return &SessionInfo{Tool: toolFake, FilesWritten: map[string]struct{}{"a.go": {}}}, nil
+	})
+
+	repoRoot := t.TempDir()
+	sessions := DetectAll(repoRoot, DetectorConfig{ToolMaxAge: map[Tool]time.Duration{toolFake: time.Hour}})
+	if gotRoot != repoRoot || gotMaxAge != time.Hour {
+		t.Errorf("detector called with (%q, %v), want (%q, 1h)", gotRoot, gotMaxAge, repoRoot)
+	}
+	if s := sessions[toolFake]; s == nil || !equal(sortedKeys(s.FilesWritten), []string{"a.go"}) {
+		t.Errorf("expected the fake session, got %+v", s)
+	}
+
+	// Registering again replaces the detector rather than adding a second
+	RegisterDetector(toolFake, func(string, time.Duration) (*SessionInfo, error) { return nil, nil })
+	if n := len(registeredDetectors()); n != len(saved)+1 {
+		t.Errorf("got %d detectors, want %d", n, len(saved)+1)
+	}
+	if sessions := DetectAll(repoRoot, DetectorConfig{}); sessions[toolFake] != nil {
+		t.Error("expected the replacement detector to be used")
+	}
+	if tool, err := ParseTool("Fake-Agent"); err != nil || tool != toolFake {
+		t.Errorf("ParseTool: got %q, %v; want the registered tool", tool, err)
+	}
+}
+
+func TestParseTool(t *testing.T) {
+	tools := []Tool{ToolClaudeCode, ToolAider, ToolCursor, ToolCopilot, ToolCodex, ToolWindsurf, ToolCline, ToolZed}
+	for _, tool := range tools {
+		for _, s := range []string{tool.String(), strings.ToUpper(tool.String())} {
+			got, err := ParseTool(s)
+			if err != nil || got != tool {
+				t.Errorf("ParseTool(%q) = %q, %v; want %q", s, got, err, tool)
+			}
+		}
+	}
+
+	for _, s := range []string{"", "vim", "claude"} {
+		if got, err := ParseTool(s); err == nil {
+			t.Errorf("ParseTool(%q) = %q, want an error", s, got)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package detector
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultGeneratedPatterns are the vendored and generated file heuristics
+// applied by DetectorConfig.SkipGenerated. Entries ending in "/" name a
+// directory and match it at any depth; other entries are globs matched
+// against the file's base name.
+var DefaultGeneratedPatterns = []string{
+	// Generated code
+	"*.pb.go",
+	"*.pb.gw.go",
+	"*_gen.go",
+	"*.gen.go",
+	"*_generated.go",
+	"zz_generated.*",
+	"*_pb2.py",
+	"*_pb2_grpc.py",
+	"*.min.js",
+	"*.min.css",
+	// Vendored dependencies and build output
+	"vendor/",
+	"node_modules/",
+	"dist/",
+}
+
+// isGenerated reports whether a slash-separated path matches any of
+// patterns, in the format of DefaultGeneratedPatterns.
+func isGenerated(p string, patterns []string) bool {
+	dirs := strings.Split(path.Dir(p), "/")
+	base := path.Base(p)
+	for _, pat := range patterns {
+		if dir, ok := strings.CutSuffix(pat, "/"); ok {
+			for _, d := range dirs {
+				if d == dir {
+					return true
+				}
+			}
+			continue
+		}
+		if ok, _ := path.Match(pat, base); ok {
+			return true
+		}
+	}
+	return false
+}
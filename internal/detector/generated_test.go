@@ -0,0 +1,75 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"api/x.pb.go", true},
+		{"vendor/foo/bar.go", true},
+		{"services/web/node_modules/react/index.js", true},
+		{"dist/app.js", true},
+		{"internal/model_gen.go", true},
+		{"pkg/apis/zz_generated.deepcopy.go", true},
+		{"static/app.min.js", true},
+		{"api/x.go", false},
+		{"vendors.go", false},
+		{"docs/distribution.md", false},
+		{"main.go", false},
+	}
+	for _, tt := range tests {
+		if got := isGenerated(tt.path, DefaultGeneratedPatterns); got != tt.want {
+			t.Errorf("isGenerated(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCodex_SkipGenerated(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"timestamp":"2026-02-10T09:00:00.000Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T09:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch api/x.pb.go vendor/foo/bar.go api/x.go gen/schema.sql\"}"}}`
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T09-00-00-aaa.jsonl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{SkipGenerated: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"api/x.go", "gen/schema.sql"}) {
+		t.Errorf("files: got %v, want [api/x.go gen/schema.sql]", got)
+	}
+
+	// Extended patterns
+	patterns := append(append([]string(nil), DefaultGeneratedPatterns...), "gen/")
+	info, err = detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{SkipGenerated: true, GeneratedPatterns: patterns})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"api/x.go"}) {
+		t.Errorf("extended patterns: got %v, want [api/x.go]", got)
+	}
+
+	// Disabled by default
+	info, err = detectCodex(context.Background(), testRepoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.FilesWritten) != 4 {
+		t.Errorf("files without SkipGenerated: got %v", sortedKeys(info.FilesWritten))
+	}
+}
@@ -0,0 +1,177 @@
+package detector
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// gitignoreRule is a single pattern line from a .gitignore file.
+type gitignoreRule struct {
+	base     string // directory of the .gitignore, relative to the repo root ("" for top-level)
+	pattern  string
+	negate   bool // "!pattern" re-includes a previously ignored path
+	dirOnly  bool // "pattern/" only matches directories
+	anchored bool // pattern contains a slash, so it matches relative to base
+}
+
+// gitignore evaluates .gitignore rules for paths in a repo. Nested
+// .gitignore files are loaded lazily as paths under them are checked.
+type gitignore struct {
+	root  string
+	rules map[string][]gitignoreRule // keyed by directory relative to root
+}
+
+func loadGitignore(repoRoot string) *gitignore {
+	return &gitignore{root: repoRoot, rules: make(map[string][]gitignoreRule)}
+}
+
+// rulesFor returns the rules from dir's .gitignore, reading it on first use.
+func (g *gitignore) rulesFor(dir string) []gitignoreRule {
+	if rules, ok := g.rules[dir]; ok {
+		return rules
+	}
+	rules := parseGitignoreFile(filepath.Join(g.root, filepath.FromSlash(dir), ".gitignore"), dir)
+	g.rules[dir] = rules
+	return rules
+}
+
+// parseGitignoreFile reads the rules from a .gitignore file. A missing file
+// yields no rules.
+func parseGitignoreFile(file, base string) []gitignoreRule {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []gitignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := gitignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		// Escaped leading "#" or "!" are literal
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ignored reports whether relPath (slash-separated, relative to the repo
+// root) is excluded by the repo's .gitignore files. As in git, a file inside
+// an ignored directory stays ignored even if a later rule negates the file.
+func (g *gitignore) ignored(relPath string) bool {
+	parts := strings.Split(path.Clean(relPath), "/")
+	for i := 1; i <= len(parts); i++ {
+		p := strings.Join(parts[:i], "/")
+		if g.matches(p, i < len(parts)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches evaluates every rule that applies to p, in order from the
+// top-level .gitignore down to p's parent directory. The last matching rule
+// wins.
+func (g *gitignore) matches(p string, isDir bool) bool {
+	dirs := []string{""}
+	segments := strings.Split(p, "/")
+	for i := 1; i < len(segments); i++ {
+		dirs = append(dirs, strings.Join(segments[:i], "/"))
+	}
+
+	ignored := false
+	for _, dir := range dirs {
+		for _, rule := range g.rulesFor(dir) {
+			if rule.match(p, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// match reports whether the rule's pattern matches p.
+func (r gitignoreRule) match(p string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	rel := p
+	if r.base != "" {
+		if !strings.HasPrefix(p, r.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(p, r.base+"/")
+	}
+	if !r.anchored {
+		return globMatch(r.pattern, path.Base(rel))
+	}
+	return globMatch(r.pattern, rel)
+}
+
+// globMatch matches a slash-separated glob against name, with "**"
+// matching zero or more whole path segments.
+func globMatch(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// gitignored returns a predicate reporting whether a written path is
+// matched by the repo's .gitignore rules. Absolute paths outside repoRoot
+// are never ignored.
+func gitignored(repoRoot string) func(p string) bool {
+	gi := loadGitignore(repoRoot)
+	return func(p string) bool {
+		rel := p
+		if filepath.IsAbs(p) {
+			r, err := filepath.Rel(repoRoot, p)
+			if err != nil || strings.HasPrefix(r, "..") {
+				return false
+			}
+			rel = filepath.ToSlash(r)
+		}
+		return gi.ignored(rel)
+	}
+}
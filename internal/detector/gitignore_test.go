@@ -0,0 +1,114 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeGitignore(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitignoreIgnored(t *testing.T) {
+	root := t.TempDir()
+	writeGitignore(t, root, `# deps
+node_modules/
+.venv
+*.log
+!keep.log
+/dist
+build/
+!build/keep.txt
+docs/**/*.tmp
+`)
+	writeGitignore(t, filepath.Join(root, "web"), `*.gen.ts
+!api.gen.ts
+`)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"node_modules/react/index.js", true},
+		{"web/node_modules/x.js", true},
+		{".venv/lib/site.py", true},
+		{"debug.log", true},
+		{"src/server.log", true},
+		{"keep.log", false},
+		{"dist/app.js", true},
+		{"web/dist/app.js", false},
+		{"build/out.o", true},
+		{"build/keep.txt", true}, // parent directory is excluded
+		{"docs/a/b/c.tmp", true},
+		{"docs/c.tmp", true},
+		{"web/types.gen.ts", true},
+		{"web/api.gen.ts", false},
+		{"types.gen.ts", false},
+		{"src/main.go", false},
+	}
+
+	gi := loadGitignore(root)
+	for _, tt := range tests {
+		if got := gi.ignored(tt.path); got != tt.want {
+			t.Errorf("ignored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCodex_RespectGitignore(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	repoRoot := t.TempDir()
+	writeGitignore(t, repoRoot, "node_modules/\n")
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"timestamp":"2026-02-10T09:00:00.000Z","type":"session_meta","payload":{"cwd":"` + repoRoot + `"}}
+{"timestamp":"2026-02-10T09:00:01.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch src/a.go node_modules/x/index.js\"}"}}
+{"timestamp":"2026-02-10T09:00:02.000Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1000},"last_token_usage":{"total_tokens":1000}}}}`
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-2026-02-10T09-00-00-aaa.jsonl"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := detectCodex(context.Background(), repoRoot, 72*time.Hour, DetectorConfig{RespectGitignore: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"src/a.go"}) {
+		t.Errorf("files: got %v, want [src/a.go]", got)
+	}
+	// Ignored paths are gone from every view of the session
+	for _, e := range info.Events {
+		if e.Path != "src/a.go" {
+			t.Errorf("events: got %q", e.Path)
+		}
+	}
+	if len(info.Events) == 0 {
+		t.Error("events: got none, want src/a.go")
+	}
+	// The turn's tokens are split over the files that remain
+	if got := info.TokensPerFile(); len(got) != 1 || got["src/a.go"] != 1000 {
+		t.Errorf("tokens by file: got %v, want only src/a.go", got)
+	}
+
+	// Disabled by default
+	info, err = detectCodex(context.Background(), repoRoot, 72*time.Hour, DetectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"node_modules/x/index.js", "src/a.go"}) {
+		t.Errorf("files without RespectGitignore: got %v", got)
+	}
+}
@@ -0,0 +1,73 @@
+package detector
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IntersectWithGitStatus returns the files the session wrote that have
+// uncommitted changes in repoRoot: modified, added, deleted, renamed or
+// untracked, as reported by git status. Both sides of a rename count as
+// changed. Written paths are compared relative to the repo root, with
+// absolute paths inside repoRoot made relative. The result is sorted.
+// When git isn't installed it returns nil without an error.
+func IntersectWithGitStatus(repoRoot string, info *SessionInfo) ([]string, error) {
+	if info == nil || len(info.FilesWritten) == 0 {
+		return nil, nil
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, nil
+	}
+	out, err := gitOutput(repoRoot, "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("git status: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git status: %w", err)
+	}
+	dirty := toSet(parseGitStatus(out))
+
+	var result []string
+	for f := range info.FilesWritten {
+		p := f
+		if filepath.IsAbs(p) {
+			rel, err := filepath.Rel(repoRoot, p)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+				continue
+			}
+			p = rel
+		}
+		p = path.Clean(filepath.ToSlash(p))
+		if _, ok := dirty[p]; ok {
+			result = append(result, p)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// parseGitStatus returns the paths listed by git status --porcelain -z.
+// Each entry is "XY PATH"; renames and copies are followed by a second
+// NUL-terminated field holding the original path, which is returned too.
+func parseGitStatus(out string) []string {
+	var paths []string
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 4 {
+			continue
+		}
+		paths = append(paths, entry[3:])
+		if strings.ContainsAny(entry[:2], "RC") && i+1 < len(fields) {
+			i++
+			paths = append(paths, fields[i])
+		}
+	}
+	return paths
+}
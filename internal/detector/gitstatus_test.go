@@ -0,0 +1,72 @@
+package detector
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGitStatus(t *testing.T) {
+	out := " M src/a.go\x00R  new name.go\x00old name.go\x00?? docs/notes.md\x00D  gone.go\x00"
+	got := parseGitStatus(out)
+	want := []string{"src/a.go", "new name.go", "old name.go", "docs/notes.md", "gone.go"}
+	if !equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIntersectWithGitStatus(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	repoRoot := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = repoRoot
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(repoRoot, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("src/a.go", "package a\n")
+	write("src/clean.go", "package a\n")
+	write("old.go", "package main\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+
+	write("src/a.go", "package a // changed\n")
+	write("src/new/b.go", "package new\n")
+	git("mv", "old.go", "renamed.go")
+
+	info := &SessionInfo{FilesWritten: toSet([]string{
+		"src/a.go",
+		filepath.Join(repoRoot, "src", "new", "b.go"),
+		"./renamed.go",
+		"src/clean.go",
+		"/elsewhere/x.go",
+	})}
+	got, err := IntersectWithGitStatus(repoRoot, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"renamed.go", "src/a.go", "src/new/b.go"}; !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := IntersectWithGitStatus(t.TempDir(), info); err == nil {
+		t.Error("expected an error outside a git repository")
+	}
+}
@@ -0,0 +1,61 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ListModels counts recent sessions per model across every repo, for a
+// global usage audit. Only tools that keep sessions in a single per-user
+// store are scanned: Claude Code (~/.claude/projects) and Codex
+// (~/.codex/sessions). Sessions count whether or not they wrote files, so
+// planning and review sessions are included. Sessions without a recorded
+// model are skipped.
+func ListModels(maxAge time.Duration) (map[string]int, error) {
+	counts := make(map[string]int)
+	count := func(info *SessionInfo) {
+		if info != nil && info.Model != "" {
+			counts[info.Model]++
+		}
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := os.ReadDir(filepath.Join(homeDir, ".claude", "projects"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, p := range projects {
+		if !p.IsDir() {
+			continue
+		}
+		paths, err := findRecentSessions(filepath.Join(homeDir, ".claude", "projects", p.Name()), maxAge)
+		if err != nil {
+			continue
+		}
+		for _, path := range paths {
+			info, err := parseClaudeSessionRaw(path, "")
+			if err == nil {
+				count(info)
+			}
+		}
+	}
+
+	paths, err := findCodexSessions(context.Background(), maxAge)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		info, err := parseCodexSessionRaw(context.Background(), path)
+		if err == nil {
+			count(info)
+		}
+	}
+
+	return counts, nil
+}
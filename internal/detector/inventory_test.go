@@ -0,0 +1,109 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListModels(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	// Claude Code sessions in two repos
+	for _, project := range []string{"-Users-jose-myproject", "-Users-jose-other"} {
+		dir := filepath.Join(homeDir, ".claude", "projects", project)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(testJSONLBasic), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Codex sessions in two repos, plus one outside the window
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rollout := func(cwd, model string) string {
+		return `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"` + cwd + `"}}
+{"timestamp":"2026-02-10T10:25:57.753Z","type":"turn_context","payload":{"model":"` + model + `"}}
+` + testCodexTouchLine
+	}
+	files := map[string]string{
+		"rollout-a.jsonl":   rollout("/Users/jose/myproject", "gpt-5-codex"),
+		"rollout-b.jsonl":   rollout("/Users/jose/other", "gpt-5-codex"),
+		"rollout-old.jsonl": rollout("/Users/jose/other", "gpt-4.1"),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(sessionDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-5 * 24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(sessionDir, "rollout-old.jsonl"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ListModels(72 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"claude-opus-4-6": 2, "gpt-5-codex": 2}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for model, n := range want {
+		if got[model] != n {
+			t.Errorf("%s: got %d sessions, want %d", model, got[model], n)
+		}
+	}
+}
+
+func TestListModels_SessionsWithoutWrites(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	// A Claude Code review session that only answered questions
+	projectDir := filepath.Join(homeDir, ".claude", "projects", "-Users-jose-myproject")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	review := `{"type":"assistant","message":{"model":"claude-haiku-4-5","role":"assistant","content":[{"type":"text","text":"Looks good."}],"usage":{"input_tokens":10,"output_tokens":5}},"timestamp":"2026-02-12T10:01:00Z"}`
+	if err := os.WriteFile(filepath.Join(projectDir, "review.jsonl"), []byte(review), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A Codex planning session that ran no commands
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	plan := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+{"timestamp":"2026-02-10T10:25:57.753Z","type":"turn_context","payload":{"model":"o3"}}`
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-plan.jsonl"), []byte(plan), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ListModels(72 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["claude-haiku-4-5"] != 1 || got["o3"] != 1 || len(got) != 2 {
+		t.Errorf("got %v, want one session each for claude-haiku-4-5 and o3", got)
+	}
+}
+
+func TestListModels_NoSessions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	got, err := ListModels(72 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected no models, got %v", got)
+	}
+}
@@ -0,0 +1,174 @@
+package detector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JetBrains Junie task detection.
+//
+// Junie runs inside IntelliJ-based IDEs and keeps its task logs in the IDE's
+// system directory, one directory per product and version:
+//   macOS:  ~/Library/Caches/JetBrains/{Product}{Version}/matterhorn/tasks/
+//   Linux:  $XDG_CACHE_HOME/JetBrains/{Product}{Version}/matterhorn/tasks/
+//           (default ~/.cache)
+//
+// Each task is a JSON file recording the project it ran in and the steps
+// Junie took:
+//   {"id": "...", "projectPath": "/path/to/repo", "model": "...",
+//    "createdAt": 1770717600000, "updatedAt": 1770717690000,
+//    "steps": [{"tool": "edit_file", "path": "src/Main.kt", "status": "success"}],
+//    "usage": {"inputTokens": 1000, "outputTokens": 200}}
+//
+// Paths are relative to the project or absolute. File edits are the steps
+// of junieWriteTools; a step counts unless its status is "error". Token
+// usage and the model are only present in newer logs.
+
+// junieWriteTools are the step tools that write files.
+var junieWriteTools = map[string]bool{
+	"create":         true,
+	"edit_file":      true,
+	"search_replace": true,
+	"write_file":     true,
+}
+
+type junieTask struct {
+	ID          string      `json:"id"`
+	ProjectPath string      `json:"projectPath"`
+	Model       string      `json:"model"`
+	CreatedAt   int64       `json:"createdAt"` // epoch ms
+	UpdatedAt   int64       `json:"updatedAt"` // epoch ms
+	Steps       []junieStep `json:"steps"`
+	Usage       struct {
+		InputTokens  int64 `json:"inputTokens"`
+		OutputTokens int64 `json:"outputTokens"`
+	} `json:"usage"`
+}
+
+type junieStep struct {
+	Tool   string `json:"tool"`
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// junieTaskDirs returns the task directories of every JetBrains IDE
+// installation for the current OS. Returns nil when unsupported or when no
+// JetBrains IDE has run.
+func junieTaskDirs() []string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	var base string
+	switch runtime.GOOS {
+	case "darwin":
+		base = filepath.Join(homeDir, "Library", "Caches", "JetBrains")
+	case "linux":
+		cacheDir := os.Getenv("XDG_CACHE_HOME")
+		if cacheDir == "" {
+			cacheDir = filepath.Join(homeDir, ".cache")
+		}
+		base = filepath.Join(cacheDir, "JetBrains")
+	default:
+		return nil
+	}
+	dirs, _ := filepath.Glob(filepath.Join(base, "*", "matterhorn", "tasks"))
+	return dirs
+}
+
+// findJunieTasks returns task logs modified within maxAge.
+func findJunieTasks(maxAge time.Duration) []string {
+	cutoff := time.Now().Add(-maxAge)
+	var tasks []string
+	for _, dir := range junieTaskDirs() {
+		paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err != nil || info.ModTime().Before(cutoff) {
+				continue
+			}
+			tasks = append(tasks, p)
+		}
+	}
+	return tasks
+}
+
+// parseJunieTask reads a task log and extracts the files written, if the
+// task's project is repoRoot. Returns nil for other projects and for tasks
+// that wrote nothing.
+func parseJunieTask(path string, repoRoot string) (*SessionInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var task junieTask
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, nil
+	}
+	if filepath.Clean(task.ProjectPath) != repoRoot {
+		return nil, nil
+	}
+
+	info := &SessionInfo{
+		Tool:         ToolJunie,
+		SessionID:    task.ID,
+		CWD:          task.ProjectPath,
+		FilesWritten: make(map[string]struct{}),
+		Model:        task.Model,
+		InputTokens:  task.Usage.InputTokens,
+		OutputTokens: task.Usage.OutputTokens,
+		TotalTokens:  task.Usage.InputTokens + task.Usage.OutputTokens,
+	}
+	for _, step := range task.Steps {
+		if !junieWriteTools[step.Tool] || step.Status == "error" {
+			continue
+		}
+		p := strings.TrimSpace(step.Path)
+		if filepath.IsAbs(p) {
+			rel := strings.TrimPrefix(p, repoRoot+"/")
+			if rel == p {
+				continue
+			}
+			p = rel
+		}
+		if p = cleanPath(p); p != "" && !strings.HasPrefix(p, "../") {
+			info.FilesWritten[p] = struct{}{}
+		}
+	}
+	if len(info.FilesWritten) == 0 {
+		return nil, nil
+	}
+
+	if task.CreatedAt > 0 && task.UpdatedAt >= task.CreatedAt {
+		info.StartedAt = time.UnixMilli(task.CreatedAt)
+		info.EndedAt = time.UnixMilli(task.UpdatedAt)
+		info.SessionDurationSec = (task.UpdatedAt - task.CreatedAt) / 1000
+	}
+	return info, nil
+}
+
+// detectJunie finds recent Junie tasks run in repoRoot and merges their
+// file sets, summing tokens and durations and keeping the model of the
+// latest task. Returns nil when no JetBrains IDE is installed or no task
+// matches.
+func detectJunie(repoRoot string, maxAge time.Duration) (*SessionInfo, error) {
+	var tasks []*SessionInfo
+	for _, p := range findJunieTasks(maxAge) {
+		task, err := parseJunieTask(p, repoRoot)
+		if err != nil || task == nil {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	// mergeSessions keeps the last model, so order the tasks by end time
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].EndedAt.Before(tasks[j].EndedAt)
+	})
+	return mergeSessions(tasks, usageSum), nil
+}
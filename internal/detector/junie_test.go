@@ -0,0 +1,129 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJunieTask = `{
+  "id": "task-1",
+  "projectPath": "/Users/jose/myproject",
+  "model": "claude-sonnet-4",
+  "createdAt": 1770717600000,
+  "updatedAt": 1770717690000,
+  "steps": [
+    {"tool": "open_file", "path": "build.gradle.kts", "status": "success"},
+    {"tool": "create", "path": "src/main/kotlin/App.kt", "status": "success"},
+    {"tool": "edit_file", "path": "/Users/jose/myproject/src/main/kotlin/Main.kt", "status": "success"},
+    {"tool": "search_replace", "path": "./src/main/kotlin/Main.kt", "status": "success"},
+    {"tool": "edit_file", "path": "src/main/kotlin/Broken.kt", "status": "error"},
+    {"tool": "edit_file", "path": "/Users/jose/other/Other.kt", "status": "success"},
+    {"tool": "write_file", "path": "../outside.kt", "status": "success"}
+  ],
+  "usage": {"inputTokens": 1000, "outputTokens": 200}
+}`
+
+// writeJunieTask writes a task log for the IDE directory ide under the
+// platform-correct JetBrains cache of homeDir (mirrors junieTaskDirs).
+func writeJunieTask(t *testing.T, homeDir, ide, name, content string) string {
+	t.Helper()
+	cacheDir := filepath.Join(homeDir, ".cache")
+	if runtime.GOOS == "darwin" {
+		cacheDir = filepath.Join(homeDir, "Library", "Caches")
+	}
+	dir := filepath.Join(cacheDir, "JetBrains", ide, "matterhorn", "tasks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestParseJunieTask(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "task-1.json")
+	if err := os.WriteFile(p, []byte(testJunieTask), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := parseJunieTask(p, testRepoRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if info.Tool != ToolJunie {
+		t.Errorf("tool: got %q", info.Tool)
+	}
+	want := []string{"src/main/kotlin/App.kt", "src/main/kotlin/Main.kt"}
+	if got := sortedKeys(info.FilesWritten); !equal(got, want) {
+		t.Errorf("files: got %v, want %v", got, want)
+	}
+	if info.Model != "claude-sonnet-4" || info.TotalTokens != 1200 || info.InputTokens != 1000 || info.OutputTokens != 200 {
+		t.Errorf("model/tokens: got %q %d (%d/%d)", info.Model, info.TotalTokens, info.InputTokens, info.OutputTokens)
+	}
+	if info.SessionDurationSec != 90 {
+		t.Errorf("duration: got %d, want 90", info.SessionDurationSec)
+	}
+
+	if info, err := parseJunieTask(p, "/Users/jose/other"); err != nil || info != nil {
+		t.Errorf("other project: got %+v, %v", info, err)
+	}
+}
+
+func TestDetectJunie(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		t.Skip("JetBrains cache location unknown on this OS")
+	}
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	if info, err := detectJunie(testRepoRoot, 72*time.Hour); err != nil || info != nil {
+		t.Fatalf("expected nil, nil without JetBrains, got %+v, %v", info, err)
+	}
+
+	writeJunieTask(t, homeDir, "IntelliJIdea2025.2", "task-1.json", testJunieTask)
+	second := strings.NewReplacer(
+		`"task-1"`, `"task-2"`,
+		"App.kt", "Config.kt",
+		`"claude-sonnet-4"`, `"gpt-5"`,
+		"1770717690000", "1770721200000",
+	).Replace(testJunieTask)
+	writeJunieTask(t, homeDir, "WebStorm2025.2", "task-2.json", second)
+	stale := writeJunieTask(t, homeDir, "IntelliJIdea2025.2", "task-old.json",
+		strings.ReplaceAll(testJunieTask, "App.kt", "Stale.kt"))
+	old := time.Now().Add(-5 * 24 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := detectJunie(testRepoRoot, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	want := []string{"src/main/kotlin/App.kt", "src/main/kotlin/Config.kt", "src/main/kotlin/Main.kt"}
+	if got := sortedKeys(info.FilesWritten); !equal(got, want) {
+		t.Errorf("files: got %v, want %v", got, want)
+	}
+	if info.Model != "gpt-5" {
+		t.Errorf("model: got %q, want the latest task's gpt-5", info.Model)
+	}
+	if info.TotalTokens != 2400 {
+		t.Errorf("tokens: got %d, want 2400", info.TotalTokens)
+	}
+
+	if info, _ := detectJunie("/Users/jose/other", 72*time.Hour); info != nil {
+		t.Errorf("expected nil for a project without tasks, got %+v", info)
+	}
+}
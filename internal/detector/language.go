@@ -0,0 +1,64 @@
+package detector
+
+import (
+	"path"
+	"strings"
+)
+
+// languageByExt maps file extensions to the language they are written in.
+var languageByExt = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".cjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".rs":    "Rust",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".swift": "Swift",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".scala": "Scala",
+	".sh":    "Shell",
+	".sql":   "SQL",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "CSS",
+}
+
+// PrimaryLanguage returns the language most of the session's written files
+// are in. It is computed on demand rather than stored, so it stays correct
+// after sessions are merged or their files filtered. See
+// detectPrimaryLanguage.
+func (s *SessionInfo) PrimaryLanguage() string {
+	return detectPrimaryLanguage(s.FilesWritten)
+}
+
+// detectPrimaryLanguage returns the language most of files are in, based on
+// their extensions. Ties go to the alphabetically first language. Returns ""
+// when no file has a recognized extension.
+func detectPrimaryLanguage(files map[string]struct{}) string {
+	counts := make(map[string]int)
+	for f := range files {
+		if lang, ok := languageByExt[strings.ToLower(path.Ext(f))]; ok {
+			counts[lang]++
+		}
+	}
+
+	best, bestCount := "", 0
+	for lang, n := range counts {
+		if n > bestCount || (n == bestCount && lang < best) {
+			best, bestCount = lang, n
+		}
+	}
+	return best
+}
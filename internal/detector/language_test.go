@@ -0,0 +1,30 @@
+package detector
+
+import "testing"
+
+func TestPrimaryLanguage(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"mostly go", []string{"main.go", "cmd/root.go", "internal/x.go", "scripts/gen.py", "README.md"}, "Go"},
+		{"tie broken alphabetically", []string{"a.py", "b.go"}, "Go"},
+		{"extensions are case-insensitive", []string{"App.TSX", "index.ts", "main.go"}, "TypeScript"},
+		{"unknown extensions only", []string{"README.md", "Makefile"}, ""},
+		{"no files", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := toSet(tt.files)
+			if got := detectPrimaryLanguage(files); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			s := &SessionInfo{FilesWritten: files}
+			if got := s.PrimaryLanguage(); got != tt.want {
+				t.Errorf("method: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
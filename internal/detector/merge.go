@@ -0,0 +1,188 @@
+package detector
+
+import (
+	"maps"
+	"path"
+	"slices"
+	"sort"
+)
+
+// mergeUsage selects how mergeSessions combines token usage and durations.
+type mergeUsage int
+
+const (
+	// usageMax keeps the token split of the largest session and the longest
+	// duration. Codex counters are cumulative, so a resumed session already
+	// includes the usage of the sessions before it.
+	usageMax mergeUsage = iota
+	// usageSum adds up the tokens and durations of independent sessions,
+	// such as separate tasks of an IDE agent.
+	usageSum
+)
+
+// mergeSessions combines sessions from one tool into a single SessionInfo:
+// file sets and actions are unioned, the last non-empty model, provider,
+// originator and source are kept, tokens and duration are combined as
+// usage says, and per-session counters are summed. Events are
+// concatenated and ordered by time. The merge is Interrupted if any input
+// was, and then keeps the last interrupted session's EndReason. Tool is
+// kept when every input shares it and left empty otherwise. Returns nil
+// for empty input.
+func mergeSessions(sessions []*SessionInfo, usage mergeUsage) *SessionInfo {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	merged := &SessionInfo{
+		Tool:             sessions[0].Tool,
+		FilesWritten:     make(map[string]struct{}),
+		DirsWritten:      make(map[string]struct{}),
+		FilesRead:        make(map[string]struct{}),
+		FileActions:      make(map[string]FileAction),
+		FilesRenamedFrom: make(map[string]struct{}),
+	}
+
+	for _, session := range sessions {
+		if session.Tool != merged.Tool {
+			merged.Tool = ""
+		}
+		for f := range session.FilesWritten {
+			merged.FilesWritten[f] = struct{}{}
+		}
+		for d := range session.DirsWritten {
+			merged.DirsWritten[d] = struct{}{}
+		}
+		for f := range session.FilesRead {
+			merged.FilesRead[f] = struct{}{}
+		}
+		for f := range session.FilesRenamedFrom {
+			merged.FilesRenamedFrom[f] = struct{}{}
+		}
+		for f, action := range session.FileActions {
+			merged.FileActions[f] = action
+		}
+		// Use the last session's model
+		if session.Model != "" {
+			merged.Model = session.Model
+		}
+		if session.Provider != "" {
+			merged.Provider = session.Provider
+		}
+		if session.Originator != "" {
+			merged.Originator = session.Originator
+		}
+		if session.Source != "" {
+			merged.Source = session.Source
+		}
+		switch usage {
+		case usageSum:
+			merged.TotalTokens += session.TotalTokens
+			merged.InputTokens += session.InputTokens
+			merged.OutputTokens += session.OutputTokens
+			merged.SessionDurationSec += session.SessionDurationSec
+		default:
+			if session.TotalTokens > merged.TotalTokens {
+				merged.TotalTokens = session.TotalTokens
+				merged.InputTokens = session.InputTokens
+				merged.OutputTokens = session.OutputTokens
+			}
+			if session.SessionDurationSec > merged.SessionDurationSec {
+				merged.SessionDurationSec = session.SessionDurationSec
+			}
+		}
+		merged.Turns = append(merged.Turns, session.Turns...)
+		merged.Events = append(merged.Events, session.Events...)
+		merged.GitActions = append(merged.GitActions, session.GitActions...)
+		merged.FailedCommands += session.FailedCommands
+		merged.RanTests = merged.RanTests || session.RanTests
+		if session.Interrupted || (!merged.Interrupted && session.EndReason != "") {
+			merged.EndReason = session.EndReason
+		}
+		merged.Interrupted = merged.Interrupted || session.Interrupted
+		merged.ExecDuration += session.ExecDuration
+		merged.LinesAdded += session.LinesAdded
+		merged.LinesRemoved += session.LinesRemoved
+		merged.NetLineDelta += session.NetLineDelta
+		merged.Errors = append(merged.Errors, session.Errors...)
+		merged.mergeSources(session)
+		if !session.StartedAt.IsZero() && (merged.StartedAt.IsZero() || session.StartedAt.Before(merged.StartedAt)) {
+			merged.StartedAt = session.StartedAt
+		}
+		if session.EndedAt.After(merged.EndedAt) {
+			merged.EndedAt = session.EndedAt
+		}
+	}
+	sort.SliceStable(merged.Events, func(i, j int) bool {
+		return merged.Events[i].Time.Before(merged.Events[j].Time)
+	})
+
+	return merged
+}
+
+// countDirs returns the number of distinct parent directories of files.
+// Top-level files share the "." directory.
+func countDirs(files map[string]struct{}) int {
+	dirs := make(map[string]struct{})
+	for f := range files {
+		dirs[path.Dir(f)] = struct{}{}
+	}
+	return len(dirs)
+}
+
+// clone returns a copy of s that shares no sets or lists with it.
+func (s *SessionInfo) clone() *SessionInfo {
+	c := *s
+	c.FilesWritten = maps.Clone(s.FilesWritten)
+	c.DirsWritten = maps.Clone(s.DirsWritten)
+	c.FilesRead = maps.Clone(s.FilesRead)
+	c.FilesRenamedFrom = maps.Clone(s.FilesRenamedFrom)
+	c.FileActions = maps.Clone(s.FileActions)
+	c.FileSources = maps.Clone(s.FileSources)
+	c.GitActions = slices.Clone(s.GitActions)
+	c.Turns = slices.Clone(s.Turns)
+	c.Events = slices.Clone(s.Events)
+	c.Errors = slices.Clone(s.Errors)
+	c.SourceFiles = slices.Clone(s.SourceFiles)
+	return &c
+}
+
+// recordSource marks src as the session file s was parsed from.
+func (s *SessionInfo) recordSource(src string) {
+	s.SourceFiles = []string{src}
+	s.FileSources = make(map[string][]string, len(s.FilesWritten))
+	for f := range s.FilesWritten {
+		s.FileSources[f] = []string{src}
+	}
+}
+
+// mergeSources adds other's provenance to s. It does nothing unless other
+// recorded any.
+func (s *SessionInfo) mergeSources(other *SessionInfo) {
+	if other.SourceFiles == nil && other.FileSources == nil {
+		return
+	}
+	s.SourceFiles = appendMissing(s.SourceFiles, other.SourceFiles...)
+	if s.FileSources == nil {
+		s.FileSources = make(map[string][]string)
+	}
+	for f, srcs := range other.FileSources {
+		s.FileSources[f] = appendMissing(s.FileSources[f], srcs...)
+	}
+}
+
+// appendMissing appends the values not already in list.
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, have := range list {
+			if have == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,165 @@
+package detector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeSessions_Empty(t *testing.T) {
+	if got := mergeSessions(nil, usageMax); got != nil {
+		t.Errorf("expected nil, got %+v", got)
+	}
+}
+
+func TestMergeSessions(t *testing.T) {
+	start := time.Date(2026, 2, 10, 10, 0, 0, 0, time.UTC)
+	a := &SessionInfo{
+		Tool:               ToolCodex,
+		FilesWritten:       map[string]struct{}{"a.go": {}, "shared.go": {}},
+		FileActions:        map[string]FileAction{"a.go": FileActionCreate},
+		Model:              "gpt-5-codex",
+		TotalTokens:        5000,
+		InputTokens:        4000,
+		OutputTokens:       1000,
+		SessionDurationSec: 600,
+		StartedAt:          start,
+		EndedAt:            start.Add(10 * time.Minute),
+		FailedCommands:     1,
+		LinesAdded:         4,
+		LinesRemoved:       1,
+		NetLineDelta:       3,
+		Events: []FileEvent{
+			{Path: "a.go", Time: start, Kind: FileEventCreate},
+			{Path: "shared.go", Time: start.Add(2 * time.Hour), Kind: FileEventWrite},
+		},
+	}
+	b := &SessionInfo{
+		Tool:               ToolCodex,
+		FilesWritten:       map[string]struct{}{"b.go": {}, "shared.go": {}},
+		DirsWritten:        map[string]struct{}{"vendor": {}},
+		GitActions:         []string{"git stash"},
+		RanTests:           true,
+		Model:              "gpt-5.3-codex",
+		TotalTokens:        2000,
+		InputTokens:        1500,
+		OutputTokens:       500,
+		SessionDurationSec: 300,
+		StartedAt:          start.Add(time.Hour),
+		EndedAt:            start.Add(time.Hour + 5*time.Minute),
+		FailedCommands:     2,
+		LinesAdded:         1,
+		LinesRemoved:       3,
+		NetLineDelta:       -2,
+		Events:             []FileEvent{{Path: "b.go", Time: start.Add(time.Hour), Kind: FileEventWrite}},
+	}
+
+	merged := mergeSessions([]*SessionInfo{a, b}, usageMax)
+	if merged.Tool != ToolCodex {
+		t.Errorf("tool: got %q, want %q", merged.Tool, ToolCodex)
+	}
+	if got := sortedKeys(merged.FilesWritten); !equal(got, []string{"a.go", "b.go", "shared.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+	if got := sortedKeys(merged.DirsWritten); !equal(got, []string{"vendor"}) {
+		t.Errorf("dirs: got %v", got)
+	}
+	if merged.FileActions["a.go"] != FileActionCreate {
+		t.Errorf("actions: got %v", merged.FileActions)
+	}
+	if !equal(merged.GitActions, []string{"git stash"}) {
+		t.Errorf("git actions: got %v", merged.GitActions)
+	}
+	if merged.Model != "gpt-5.3-codex" {
+		t.Errorf("model: got %q, want latest", merged.Model)
+	}
+	if merged.TotalTokens != 5000 || merged.InputTokens != 4000 || merged.OutputTokens != 1000 {
+		t.Errorf("tokens: got %d (%d/%d), want the largest session's split", merged.TotalTokens, merged.InputTokens, merged.OutputTokens)
+	}
+	if merged.SessionDurationSec != 600 {
+		t.Errorf("duration: got %d, want 600", merged.SessionDurationSec)
+	}
+	if !merged.StartedAt.Equal(a.StartedAt) || !merged.EndedAt.Equal(b.EndedAt) {
+		t.Errorf("window: got %v - %v", merged.StartedAt, merged.EndedAt)
+	}
+	if merged.FailedCommands != 3 || merged.LinesAdded != 5 || merged.LinesRemoved != 4 || merged.NetLineDelta != 1 {
+		t.Errorf("counters: got failed=%d +%d -%d net=%d", merged.FailedCommands, merged.LinesAdded, merged.LinesRemoved, merged.NetLineDelta)
+	}
+	if !merged.RanTests {
+		t.Error("ran tests: got false, want true from b")
+	}
+	var order []string
+	for _, e := range merged.Events {
+		order = append(order, e.Path)
+	}
+	if !equal(order, []string{"a.go", "b.go", "shared.go"}) {
+		t.Errorf("events: got %v, want ordered by time", order)
+	}
+}
+
+func TestMergeSessions_SumUsage(t *testing.T) {
+	start := time.Date(2026, 2, 10, 10, 0, 0, 0, time.UTC)
+	merged := mergeSessions([]*SessionInfo{
+		{
+			Tool:               ToolCline,
+			FilesWritten:       map[string]struct{}{"a.go": {}},
+			Model:              "claude-sonnet-4",
+			TotalTokens:        5000,
+			InputTokens:        4000,
+			OutputTokens:       1000,
+			SessionDurationSec: 600,
+			StartedAt:          start,
+			EndedAt:            start.Add(10 * time.Minute),
+		},
+		{
+			Tool:               ToolCline,
+			FilesWritten:       map[string]struct{}{"b.go": {}},
+			TotalTokens:        2000,
+			InputTokens:        1500,
+			OutputTokens:       500,
+			SessionDurationSec: 300,
+			StartedAt:          start.Add(time.Hour),
+			EndedAt:            start.Add(time.Hour + 5*time.Minute),
+		},
+	}, usageSum)
+	if merged.TotalTokens != 7000 || merged.InputTokens != 5500 || merged.OutputTokens != 1500 {
+		t.Errorf("tokens: got %d (%d/%d), want the sum 7000 (5500/1500)", merged.TotalTokens, merged.InputTokens, merged.OutputTokens)
+	}
+	if merged.SessionDurationSec != 900 {
+		t.Errorf("duration: got %d, want the sum 900", merged.SessionDurationSec)
+	}
+	if merged.Model != "claude-sonnet-4" {
+		t.Errorf("model: got %q, want the last non-empty one", merged.Model)
+	}
+	if !merged.StartedAt.Equal(start) || !merged.EndedAt.Equal(start.Add(time.Hour+5*time.Minute)) {
+		t.Errorf("window: got %v - %v", merged.StartedAt, merged.EndedAt)
+	}
+}
+
+func TestMergeSessions_MixedTools(t *testing.T) {
+	merged := mergeSessions([]*SessionInfo{
+		{Tool: ToolCodex, FilesWritten: map[string]struct{}{"a.go": {}}},
+		{Tool: ToolClaudeCode, FilesWritten: map[string]struct{}{"b.go": {}}},
+	}, usageMax)
+	if merged.Tool != "" {
+		t.Errorf("tool: got %q, want empty for mixed inputs", merged.Tool)
+	}
+	if got := sortedKeys(merged.FilesWritten); !equal(got, []string{"a.go", "b.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+}
+
+func TestMergeSessions_Interrupted(t *testing.T) {
+	merged := mergeSessions([]*SessionInfo{
+		{EndReason: "completed"},
+		{EndReason: "interrupted", Interrupted: true},
+		{EndReason: "completed"},
+	}, usageMax)
+	if !merged.Interrupted || merged.EndReason != "interrupted" {
+		t.Errorf("got interrupted=%v reason=%q, want the interruption kept", merged.Interrupted, merged.EndReason)
+	}
+
+	merged = mergeSessions([]*SessionInfo{{EndReason: "completed"}, {}}, usageMax)
+	if merged.Interrupted || merged.EndReason != "completed" {
+		t.Errorf("got interrupted=%v reason=%q, want completed", merged.Interrupted, merged.EndReason)
+	}
+}
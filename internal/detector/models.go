@@ -0,0 +1,104 @@
+package detector
+
+import "strings"
+
+// ModelPrice is the list price of a model in USD per million tokens.
+type ModelPrice struct {
+	InputPerMTok  float64 `json:"input_per_mtok"`
+	OutputPerMTok float64 `json:"output_per_mtok"`
+}
+
+// modelPrices holds published list prices keyed by model name prefix.
+// Lookups use the longest matching prefix, so dated or suffixed model IDs
+// (e.g. claude-sonnet-4-20250514) resolve to their family entry.
+var modelPrices = map[string]ModelPrice{
+	"gpt-4o":          {InputPerMTok: 2.50, OutputPerMTok: 10},
+	"gpt-4.1":         {InputPerMTok: 2, OutputPerMTok: 8},
+	"gpt-5":           {InputPerMTok: 1.25, OutputPerMTok: 10},
+	"gpt-5-mini":      {InputPerMTok: 0.25, OutputPerMTok: 2},
+	"o3":              {InputPerMTok: 2, OutputPerMTok: 8},
+	"o4-mini":         {InputPerMTok: 1.10, OutputPerMTok: 4.40},
+	"claude-opus-4":   {InputPerMTok: 15, OutputPerMTok: 75},
+	"claude-opus-4-5": {InputPerMTok: 5, OutputPerMTok: 25},
+	"claude-opus-4-6": {InputPerMTok: 5, OutputPerMTok: 25},
+	"claude-sonnet-4": {InputPerMTok: 3, OutputPerMTok: 15},
+	"claude-haiku-4":  {InputPerMTok: 1, OutputPerMTok: 5},
+}
+
+// modelContextWindows holds context window sizes in tokens, keyed and
+// looked up the same way as modelPrices.
+var modelContextWindows = map[string]int64{
+	"gpt-4o":          128000,
+	"gpt-4.1":         1047576,
+	"gpt-5":           400000,
+	"gpt-5-mini":      400000,
+	"o3":              200000,
+	"o4-mini":         200000,
+	"claude-opus-4":   200000,
+	"claude-opus-4-5": 200000,
+	"claude-opus-4-6": 200000,
+	"claude-sonnet-4": 200000,
+	"claude-haiku-4":  200000,
+}
+
+// modelProviders maps model name prefixes to the vendor serving them, looked
+// up the same way as modelPrices.
+var modelProviders = map[string]string{
+	"gpt-":    "openai",
+	"o1":      "openai",
+	"o3":      "openai",
+	"o4-":     "openai",
+	"codex-":  "openai",
+	"claude-": "anthropic",
+	"gemini-": "google",
+}
+
+// ModelProvider returns the vendor of the session's model, e.g. "openai" or
+// "anthropic": the Provider the tool recorded, else one inferred from the
+// model name, or "" for unknown models.
+func (s *SessionInfo) ModelProvider() string {
+	if s.Provider != "" {
+		return s.Provider
+	}
+	provider, _ := lookupModel(modelProviders, s.Model)
+	return provider
+}
+
+// lookupModel returns the value whose key is the longest prefix of model.
+func lookupModel[V any](table map[string]V, model string) (V, bool) {
+	var best V
+	bestLen := -1
+	for prefix, v := range table {
+		if strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
+			best, bestLen = v, len(prefix)
+		}
+	}
+	return best, bestLen >= 0
+}
+
+// EstimatedCost returns an approximate USD cost for the session based on
+// list prices. It ignores cache discounts and returns 0 for unknown models
+// or sessions without an input/output token split.
+func (s *SessionInfo) EstimatedCost() float64 {
+	return s.estimatedCost(modelPrices)
+}
+
+// estimatedCost prices the session against the given table.
+func (s *SessionInfo) estimatedCost(prices map[string]ModelPrice) float64 {
+	price, ok := lookupModel(prices, s.Model)
+	if !ok {
+		return 0
+	}
+	return float64(s.InputTokens)*price.InputPerMTok/1e6 +
+		float64(s.OutputTokens)*price.OutputPerMTok/1e6
+}
+
+// ContextUtilization returns the session's total tokens as a fraction of the
+// model's context window. Returns 0 for unknown models.
+func (s *SessionInfo) ContextUtilization() float64 {
+	window, ok := lookupModel(modelContextWindows, s.Model)
+	if !ok || window == 0 {
+		return 0
+	}
+	return float64(s.TotalTokens) / float64(window)
+}
@@ -0,0 +1,52 @@
+package detector
+
+import "testing"
+
+func TestContextUtilization(t *testing.T) {
+	tests := []struct {
+		model  string
+		tokens int64
+		want   float64
+	}{
+		{"claude-sonnet-4-20250514", 50000, 0.25},
+		{"gpt-5-codex", 100000, 0.25},
+		{"gpt-4o", 128000, 1},
+		{"unknown-model", 50000, 0},
+		{"", 50000, 0},
+	}
+
+	for _, tt := range tests {
+		s := &SessionInfo{Model: tt.model, TotalTokens: tt.tokens}
+		if got := s.ContextUtilization(); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestModelProvider(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"gpt-5-codex", "openai"},
+		{"o4-mini", "openai"},
+		{"claude-sonnet-4-20250514", "anthropic"},
+		{"gemini-2.5-pro", "google"},
+		{"unknown-model", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		s := &SessionInfo{Model: tt.model}
+		if got := s.ModelProvider(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestModelProvider_Recorded(t *testing.T) {
+	s := &SessionInfo{Model: "gpt-5-codex", Provider: "azure"}
+	if got := s.ModelProvider(); got != "azure" {
+		t.Errorf("got %q, want the recorded provider", got)
+	}
+}
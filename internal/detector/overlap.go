@@ -0,0 +1,45 @@
+package detector
+
+import (
+	"sort"
+	"time"
+)
+
+// OverlappingSessions groups sessions whose [StartedAt, EndedAt] windows
+// intersect, directly or through a chain of overlapping sessions. Only
+// groups of two or more sessions are returned, ordered by start time.
+// Sessions without both timestamps are ignored.
+func OverlappingSessions(sessions []*SessionInfo) [][]*SessionInfo {
+	var timed []*SessionInfo
+	for _, s := range sessions {
+		if !s.StartedAt.IsZero() && !s.EndedAt.IsZero() {
+			timed = append(timed, s)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].StartedAt.Before(timed[j].StartedAt)
+	})
+
+	var groups [][]*SessionInfo
+	var current []*SessionInfo
+	var currentEnd time.Time
+	flush := func() {
+		if len(current) > 1 {
+			groups = append(groups, current)
+		}
+	}
+	for _, s := range timed {
+		if len(current) > 0 && !s.StartedAt.After(currentEnd) {
+			current = append(current, s)
+			if s.EndedAt.After(currentEnd) {
+				currentEnd = s.EndedAt
+			}
+			continue
+		}
+		flush()
+		current = []*SessionInfo{s}
+		currentEnd = s.EndedAt
+	}
+	flush()
+	return groups
+}
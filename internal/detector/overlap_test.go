@@ -0,0 +1,48 @@
+package detector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverlappingSessions(t *testing.T) {
+	at := func(h, m int) time.Time {
+		return time.Date(2026, 2, 10, h, m, 0, 0, time.UTC)
+	}
+	codex := &SessionInfo{Tool: ToolCodex, StartedAt: at(10, 0), EndedAt: at(11, 0)}
+	claude := &SessionInfo{Tool: ToolClaudeCode, StartedAt: at(10, 30), EndedAt: at(12, 0)}
+	aider := &SessionInfo{Tool: ToolAider, StartedAt: at(14, 0), EndedAt: at(15, 0)}
+	untimed := &SessionInfo{Tool: ToolCursor}
+
+	groups := OverlappingSessions([]*SessionInfo{aider, claude, untimed, codex})
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(groups))
+	}
+	g := groups[0]
+	if len(g) != 2 || g[0] != codex || g[1] != claude {
+		t.Errorf("group: got %v, want [codex claude]", toolsOf(g))
+	}
+}
+
+func TestOverlappingSessions_Chain(t *testing.T) {
+	at := func(h int) time.Time {
+		return time.Date(2026, 2, 10, h, 0, 0, 0, time.UTC)
+	}
+	// a overlaps b, b overlaps c, but a and c don't touch directly
+	a := &SessionInfo{Tool: ToolCodex, StartedAt: at(9), EndedAt: at(11)}
+	b := &SessionInfo{Tool: ToolClaudeCode, StartedAt: at(10), EndedAt: at(13)}
+	c := &SessionInfo{Tool: ToolAider, StartedAt: at(12), EndedAt: at(14)}
+
+	groups := OverlappingSessions([]*SessionInfo{c, a, b})
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Fatalf("expected one group of 3, got %v", groups)
+	}
+}
+
+func toolsOf(sessions []*SessionInfo) []Tool {
+	var tools []Tool
+	for _, s := range sessions {
+		tools = append(tools, s.Tool)
+	}
+	return tools
+}
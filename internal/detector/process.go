@@ -14,6 +14,8 @@ var processNames = map[string]Tool{
 	"github-copilot": ToolCopilot,
 	"aider":          ToolAider,
 	"codex":          ToolCodex,
+	"Windsurf":       ToolWindsurf,
+	"windsurf":       ToolWindsurf,
 }
 
 // detectProcesses checks for running AI tool processes.
@@ -0,0 +1,273 @@
+package detector
+
+import (
+	"embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader lists the WriteCSV columns in order.
+var csvHeader = []string{"tool", "model", "start", "end", "duration_sec", "total_tokens", "files_written", "cost_usd"}
+
+// WriteCSV writes a header row followed by one row per session. Start and
+// end are RFC 3339 UTC timestamps, empty when unknown. files_written is the
+// sorted, semicolon-joined list of written files, and cost_usd the
+// estimated list-price cost. An empty input produces only the header.
+func WriteCSV(w io.Writer, sessions []*SessionInfo) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		row := []string{
+			string(s.Tool),
+			s.Model,
+			formatCSVTime(s.StartedAt),
+			formatCSVTime(s.EndedAt),
+			strconv.FormatInt(s.SessionDurationSec, 10),
+			strconv.FormatInt(s.TotalTokens, 10),
+			strings.Join(sortedKeys(s.FilesWritten), ";"),
+			fmt.Sprintf("%.4f", s.EstimatedCost()),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// fileAttribution is one WriteFileAttributions record.
+type fileAttribution struct {
+	Tool         Tool   `json:"tool"`
+	File         string `json:"file"`
+	Model        string `json:"model,omitempty"`
+	SessionStart string `json:"session_start,omitempty"`
+}
+
+// WriteFileAttributions writes one JSON Lines record per written file:
+// {tool, file, model, session_start}. Sessions are visited in order and
+// their files in sorted order; a file written by several sessions of the
+// same tool is reported once, for the first of them. session_start is an
+// RFC 3339 UTC timestamp, omitted when unknown.
+func WriteFileAttributions(w io.Writer, sessions []*SessionInfo) error {
+	enc := json.NewEncoder(w)
+	seen := make(map[Tool]map[string]bool)
+	for _, s := range sessions {
+		if seen[s.Tool] == nil {
+			seen[s.Tool] = make(map[string]bool)
+		}
+		for _, f := range sortedKeys(s.FilesWritten) {
+			if seen[s.Tool][f] {
+				continue
+			}
+			seen[s.Tool][f] = true
+			rec := fileAttribution{
+				Tool:         s.Tool,
+				File:         f,
+				Model:        s.Model,
+				SessionStart: formatCSVTime(s.StartedAt),
+			}
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// markdownMaxFiles is the largest file list RenderMarkdown prints in full.
+// Longer lists are summarized by directory.
+const markdownMaxFiles = 20
+
+// markdownTopDirs is how many directories a collapsed file list names.
+const markdownTopDirs = 5
+
+// markdownTool is the combined activity of one tool's sessions in
+// RenderMarkdown.
+type markdownTool struct {
+	tool        Tool
+	sessions    int
+	models      []string
+	durationSec int64
+	tokens      int64
+	files       map[string]struct{}
+}
+
+// groupByTool combines sessions per tool, ordered by tool name. Models are
+// listed in the order first seen; durations and tokens are summed and
+// written files unioned.
+func groupByTool(sessions []*SessionInfo) []*markdownTool {
+	byTool := make(map[Tool]*markdownTool)
+	var groups []*markdownTool
+	for _, s := range sessions {
+		g := byTool[s.Tool]
+		if g == nil {
+			g = &markdownTool{tool: s.Tool, files: make(map[string]struct{})}
+			byTool[s.Tool] = g
+			groups = append(groups, g)
+		}
+		g.sessions++
+		if s.Model != "" {
+			g.models = appendMissing(g.models, s.Model)
+		}
+		g.durationSec += s.SessionDurationSec
+		g.tokens += s.TotalTokens
+		for f := range s.FilesWritten {
+			g.files[f] = struct{}{}
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].tool < groups[j].tool })
+	return groups
+}
+
+// RenderMarkdown renders a Markdown summary with one section per tool,
+// ordered by tool name. Each section combines the tool's sessions: the
+// models used, total duration and token count, and the written files
+// grouped by directory. File lists longer than 20 entries are collapsed
+// into a count with the busiest directories.
+func RenderMarkdown(sessions []*SessionInfo) string {
+	var b strings.Builder
+	for i, g := range groupByTool(sessions) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %s\n\n", g.tool)
+		if g.sessions > 1 {
+			fmt.Fprintf(&b, "- Sessions: %d\n", g.sessions)
+		}
+		switch len(g.models) {
+		case 0:
+		case 1:
+			fmt.Fprintf(&b, "- Model: %s\n", g.models[0])
+		default:
+			fmt.Fprintf(&b, "- Models: %s\n", strings.Join(g.models, ", "))
+		}
+		if g.durationSec > 0 {
+			fmt.Fprintf(&b, "- Duration: %s\n", time.Duration(g.durationSec)*time.Second)
+		}
+		if g.tokens > 0 {
+			fmt.Fprintf(&b, "- Tokens: %d\n", g.tokens)
+		}
+
+		files := sortedKeys(g.files)
+		fmt.Fprintf(&b, "- Files written: %d\n", len(files))
+		if len(files) == 0 {
+			continue
+		}
+
+		dirs, byDir := groupByDir(files)
+		if len(files) > markdownMaxFiles {
+			sort.SliceStable(dirs, func(i, j int) bool {
+				return len(byDir[dirs[i]]) > len(byDir[dirs[j]])
+			})
+			if len(dirs) > markdownTopDirs {
+				dirs = dirs[:markdownTopDirs]
+			}
+			b.WriteString("- Top directories:\n")
+			for _, dir := range dirs {
+				n, unit := len(byDir[dir]), "files"
+				if n == 1 {
+					unit = "file"
+				}
+				fmt.Fprintf(&b, "  - `%s` (%d %s)\n", dir, n, unit)
+			}
+			continue
+		}
+
+		b.WriteString("\n")
+		for _, dir := range dirs {
+			fmt.Fprintf(&b, "- `%s`\n", dir)
+			for _, f := range byDir[dir] {
+				fmt.Fprintf(&b, "  - `%s`\n", path.Base(f))
+			}
+		}
+	}
+	return b.String()
+}
+
+//go:embed templates/report.html
+var reportTemplates embed.FS
+
+// htmlReport is parsed once from the embedded report template.
+var htmlReport = template.Must(template.ParseFS(reportTemplates, "templates/report.html"))
+
+// htmlSession is one session row of the HTML report.
+type htmlSession struct {
+	Tool     Tool
+	Model    string
+	Started  string
+	Duration string
+	Tokens   int64
+	Files    []string
+}
+
+// RenderHTML renders a self-contained HTML page with a table of sessions:
+// tool, model, start time, duration and tokens, plus an expandable list of
+// each session's written files in sorted order. Paths and model names are
+// escaped by html/template.
+func RenderHTML(sessions []*SessionInfo) (string, error) {
+	rows := make([]htmlSession, 0, len(sessions))
+	for _, s := range sessions {
+		row := htmlSession{
+			Tool:   s.Tool,
+			Model:  s.Model,
+			Tokens: s.TotalTokens,
+			Files:  sortedKeys(s.FilesWritten),
+		}
+		if !s.StartedAt.IsZero() {
+			row.Started = s.StartedAt.Local().Format("2006-01-02 15:04")
+		}
+		if s.SessionDurationSec > 0 {
+			row.Duration = (time.Duration(s.SessionDurationSec) * time.Second).String()
+		}
+		rows = append(rows, row)
+	}
+
+	var b strings.Builder
+	if err := htmlReport.Execute(&b, rows); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// groupByDir groups sorted file paths by their parent directory, returning
+// the directories in sorted order. Top-level files are grouped under "./".
+func groupByDir(files []string) ([]string, map[string][]string) {
+	byDir := make(map[string][]string)
+	var dirs []string
+	for _, f := range files {
+		dir := strings.TrimSuffix(path.Dir(f), "/") + "/"
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], f)
+	}
+	sort.Strings(dirs)
+	return dirs, byDir
+}
+
+// sortedKeys returns the keys of a set in sorted order.
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,266 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCSV(t *testing.T) {
+	sessions := []*SessionInfo{
+		{
+			Tool:               ToolCodex,
+			Model:              "gpt-5-codex",
+			FilesWritten:       map[string]struct{}{"b.go": {}, "a.go": {}, "docs/a,b.md": {}},
+			TotalTokens:        1200000,
+			InputTokens:        1000000,
+			OutputTokens:       200000,
+			SessionDurationSec: 92,
+			StartedAt:          time.Date(2026, 2, 10, 10, 25, 0, 0, time.UTC),
+			EndedAt:            time.Date(2026, 2, 10, 10, 26, 32, 0, time.UTC),
+		},
+		{
+			Tool:         ToolAider,
+			FilesWritten: map[string]struct{}{"main.py": {}},
+		},
+	}
+
+	var buf strings.Builder
+	if err := WriteCSV(&buf, sessions); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d lines: %q", len(lines), buf.String())
+	}
+	if lines[0] != "tool,model,start,end,duration_sec,total_tokens,files_written,cost_usd" {
+		t.Errorf("header: got %q", lines[0])
+	}
+	// Files are sorted and the list is quoted because a path contains a comma.
+	// 1M input * $1.25 + 0.2M output * $10 = $3.25
+	want := `codex,gpt-5-codex,2026-02-10T10:25:00Z,2026-02-10T10:26:32Z,92,1200000,"a.go;b.go;docs/a,b.md",3.2500`
+	if lines[1] != want {
+		t.Errorf("row: got %q, want %q", lines[1], want)
+	}
+	if lines[2] != "aider,,,,0,0,main.py,0.0000" {
+		t.Errorf("row without timestamps: got %q", lines[2])
+	}
+}
+
+func TestWriteCSV_Empty(t *testing.T) {
+	var buf strings.Builder
+	if err := WriteCSV(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "tool,model,start,end,duration_sec,total_tokens,files_written,cost_usd\n" {
+		t.Errorf("got %q, want header only", got)
+	}
+}
+
+func TestWriteFileAttributions(t *testing.T) {
+	start := time.Date(2026, 2, 10, 10, 0, 0, 0, time.UTC)
+	sessions := []*SessionInfo{
+		{
+			Tool:         ToolCodex,
+			Model:        "gpt-5-codex",
+			FilesWritten: map[string]struct{}{"b.go": {}, "a.go": {}},
+			StartedAt:    start,
+		},
+		{
+			// a.go was already attributed to Codex by the first session
+			Tool:         ToolCodex,
+			Model:        "gpt-5.3-codex",
+			FilesWritten: map[string]struct{}{"a.go": {}, "c.go": {}},
+			StartedAt:    start.Add(time.Hour),
+		},
+		{
+			Tool:         ToolAider,
+			FilesWritten: map[string]struct{}{"a.go": {}},
+		},
+	}
+
+	var b strings.Builder
+	if err := WriteFileAttributions(&b, sessions); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"tool":"codex","file":"a.go","model":"gpt-5-codex","session_start":"2026-02-10T10:00:00Z"}
+{"tool":"codex","file":"b.go","model":"gpt-5-codex","session_start":"2026-02-10T10:00:00Z"}
+{"tool":"codex","file":"c.go","model":"gpt-5.3-codex","session_start":"2026-02-10T11:00:00Z"}
+{"tool":"aider","file":"a.go"}
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	sessions := []*SessionInfo{
+		{
+			Tool:               ToolClaudeCode,
+			Model:              "claude-sonnet-4",
+			FilesWritten:       toSet([]string{"src/b.go", "README.md", "src/a.go"}),
+			TotalTokens:        45000,
+			SessionDurationSec: 754,
+		},
+		{
+			Tool:         ToolAider,
+			FilesWritten: toSet([]string{"main.py"}),
+		},
+		{
+			Tool:               ToolClaudeCode,
+			Model:              "claude-opus-4",
+			FilesWritten:       toSet([]string{"docs/guide.md", "src/a.go"}),
+			TotalTokens:        5000,
+			SessionDurationSec: 66,
+		},
+	}
+
+	want := "## aider\n\n" +
+		"- Files written: 1\n\n" +
+		"- `./`\n" +
+		"  - `main.py`\n" +
+		"\n## claude-code\n\n" +
+		"- Sessions: 2\n" +
+		"- Models: claude-sonnet-4, claude-opus-4\n" +
+		"- Duration: 13m40s\n" +
+		"- Tokens: 50000\n" +
+		"- Files written: 4\n\n" +
+		"- `./`\n" +
+		"  - `README.md`\n" +
+		"- `docs/`\n" +
+		"  - `guide.md`\n" +
+		"- `src/`\n" +
+		"  - `a.go`\n" +
+		"  - `b.go`\n"
+	if got := RenderMarkdown(sessions); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderMarkdown_CollapsesLongLists(t *testing.T) {
+	var files []string
+	for i := 0; i < 12; i++ {
+		files = append(files, fmt.Sprintf("src/f%02d.go", i))
+	}
+	for i := 0; i < 6; i++ {
+		files = append(files, fmt.Sprintf("test/t%d.go", i))
+	}
+	for _, d := range []string{"a", "b", "c", "d", "e"} {
+		files = append(files, d+"/x.go")
+	}
+
+	got := RenderMarkdown([]*SessionInfo{{Tool: ToolCodex, FilesWritten: toSet(files)}})
+	want := "## codex\n\n" +
+		"- Files written: 23\n" +
+		"- Top directories:\n" +
+		"  - `src/` (12 files)\n" +
+		"  - `test/` (6 files)\n" +
+		"  - `a/` (1 file)\n" +
+		"  - `b/` (1 file)\n" +
+		"  - `c/` (1 file)\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	sessions := []*SessionInfo{
+		{
+			Tool:               ToolCodex,
+			Model:              "gpt-5<script>",
+			FilesWritten:       map[string]struct{}{"b.go": {}, "a.go": {}, `docs/<x>&"y".md`: {}},
+			TotalTokens:        1200,
+			SessionDurationSec: 92,
+		},
+		{
+			Tool:         ToolAider,
+			FilesWritten: map[string]struct{}{"main.py": {}},
+		},
+	}
+
+	got, err := RenderHTML(sessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<td>codex</td>",
+		"gpt-5&lt;script&gt;",
+		"docs/&lt;x&gt;&amp;&#34;y&#34;.md",
+		"<td class=\"num\">1m32s</td>",
+		"<td class=\"num\">1200</td>",
+		"<summary>3 files</summary>",
+		"<summary>1 file</summary>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<script>") {
+		t.Error("model name was not escaped")
+	}
+	if a, b := strings.Index(got, "<li>a.go</li>"), strings.Index(got, "<li>b.go</li>"); a < 0 || b < a {
+		t.Errorf("files not listed in sorted order:\n%s", got)
+	}
+
+	empty, err := RenderHTML(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(empty, "No AI sessions found.") {
+		t.Errorf("empty report: got\n%s", empty)
+	}
+}
+
+func TestSessionInfoSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		s    *SessionInfo
+		want string
+	}{
+		{
+			name: "multiple files",
+			s: &SessionInfo{
+				Tool:               ToolCodex,
+				Model:              "gpt-5.3-codex",
+				FilesWritten:       map[string]struct{}{"a.go": {}, "b.go": {}, "c.go": {}},
+				TotalTokens:        18_500,
+				SessionDurationSec: 92,
+			},
+			want: "codex · gpt-5.3-codex · 3 files · 18.5k tokens · 1m32s",
+		},
+		{
+			name: "zero tokens",
+			s: &SessionInfo{
+				Tool:               ToolAider,
+				FilesWritten:       map[string]struct{}{"main.py": {}},
+				SessionDurationSec: 5,
+			},
+			want: "aider · 1 file · 5s",
+		},
+		{
+			name: "millions of tokens",
+			s:    &SessionInfo{Tool: ToolClaudeCode, Model: "claude-opus-4", TotalTokens: 2_000_000},
+			want: "claude-code · claude-opus-4 · 0 files · 2M tokens",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Summary(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbbreviateCount(t *testing.T) {
+	for n, want := range map[int64]string{
+		0: "0", 999: "999", 1000: "1k", 18_499: "18.5k", 999_949: "999.9k",
+		999_950: "1M", 1_250_000: "1.2M", 12_000_000: "12M",
+	} {
+		if got := abbreviateCount(n); got != want {
+			t.Errorf("%d: got %q, want %q", n, got, want)
+		}
+	}
+}
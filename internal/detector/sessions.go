@@ -0,0 +1,62 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+)
+
+// SessionRef identifies a session file without parsing it.
+type SessionRef struct {
+	Path    string
+	ModTime time.Time
+	CWD     string // working directory the session ran in
+	Tool    Tool
+}
+
+// ListSessions returns the session files modified within maxAge that ran
+// in repoRoot, most recently modified first, so a caller can offer a
+// choice before parsing any of them. Codex rollouts are matched on the cwd
+// of their session_meta record, read from the first few lines only, and
+// Claude Code sessions by their project directory. An empty repoRoot lists
+// Codex rollouts for every repo.
+func ListSessions(repoRoot string, maxAge time.Duration) ([]SessionRef, error) {
+	paths, err := findCodexSessions(context.Background(), maxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]SessionRef, 0, len(paths))
+	buf := make([]byte, 0, 64*1024)
+	for _, p := range paths {
+		cwd := codexRolloutCWD(p, buf)
+		if cwd == "" || (repoRoot != "" && !cwdMatches(cwd, repoRoot, false)) {
+			continue
+		}
+		st, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		refs = append(refs, SessionRef{Path: p, ModTime: st.ModTime(), CWD: cwd, Tool: ToolCodex})
+	}
+
+	if dir := claudeSessionDir(repoRoot); repoRoot != "" && dir != "" {
+		claudePaths, err := findRecentSessions(dir, maxAge)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, p := range claudePaths {
+			st, err := os.Stat(p)
+			if err != nil {
+				continue
+			}
+			refs = append(refs, SessionRef{Path: p, ModTime: st.ModTime(), CWD: repoRoot, Tool: ToolClaudeCode})
+		}
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].ModTime.After(refs[j].ModTime)
+	})
+	return refs, nil
+}
@@ -0,0 +1,83 @@
+package detector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListSessions(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	meta := func(cwd string) string {
+		return `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"` + cwd + `"}}` + "\n"
+	}
+	now := time.Now()
+	write := func(path string, data []byte, age time.Duration) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		mod := now.Add(-age)
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	codexMatch := filepath.Join(sessionDir, "rollout-a.jsonl")
+	write(codexMatch, []byte(meta(testRepoRoot)+testCodexTouchLine), 3*time.Hour)
+	write(filepath.Join(sessionDir, "rollout-other.jsonl"), []byte(meta("/Users/jose/other")), time.Hour)
+	write(filepath.Join(sessionDir, "rollout-old.jsonl"), []byte(meta(testRepoRoot)), 5*24*time.Hour)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write([]byte(meta(testRepoRoot))); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	codexGz := filepath.Join(sessionDir, "rollout-b.jsonl.gz")
+	write(codexGz, gz.Bytes(), 2*time.Hour)
+
+	claude := filepath.Join(claudeSessionDir(testRepoRoot), "session.jsonl")
+	write(claude, []byte("{}\n"), time.Minute)
+
+	refs, err := ListSessions(testRepoRoot, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SessionRef{
+		{Path: claude, CWD: testRepoRoot, Tool: ToolClaudeCode},
+		{Path: codexGz, CWD: testRepoRoot, Tool: ToolCodex},
+		{Path: codexMatch, CWD: testRepoRoot, Tool: ToolCodex},
+	}
+	if len(refs) != len(want) {
+		t.Fatalf("got %d sessions, want %d: %+v", len(refs), len(want), refs)
+	}
+	for i, w := range want {
+		got := refs[i]
+		if got.Path != w.Path || got.CWD != w.CWD || got.Tool != w.Tool || got.ModTime.IsZero() {
+			t.Errorf("session %d: got %+v, want %+v", i, got, w)
+		}
+	}
+
+	all, err := ListSessions("", 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("all repos: got %d Codex sessions, want 3: %+v", len(all), all)
+	}
+}
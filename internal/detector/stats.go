@@ -0,0 +1,60 @@
+package detector
+
+// Stats summarizes a set of sessions.
+type Stats struct {
+	Sessions         int
+	UniqueFiles      int // distinct written files, not a sum over sessions
+	TotalTokens      int64
+	InputTokens      int64 // sum of the sessions that report a token split
+	OutputTokens     int64 // sum of the sessions that report a token split
+	TotalDurationSec int64
+	ByTool           map[Tool]ToolStats
+}
+
+// ToolStats is the share of Stats contributed by one tool.
+type ToolStats struct {
+	Sessions         int
+	UniqueFiles      int
+	TotalTokens      int64
+	InputTokens      int64
+	OutputTokens     int64
+	TotalDurationSec int64
+}
+
+// AggregateStats totals sessions overall and per tool. Token counts and
+// durations are summed; UniqueFiles counts each written path once, however
+// many sessions or tools wrote it.
+func AggregateStats(sessions []*SessionInfo) Stats {
+	stats := Stats{ByTool: make(map[Tool]ToolStats)}
+	files := make(map[string]struct{})
+	toolFiles := make(map[Tool]map[string]struct{})
+	for _, s := range sessions {
+		if s == nil {
+			continue
+		}
+		ts := stats.ByTool[s.Tool]
+		ts.Sessions++
+		ts.TotalTokens += s.TotalTokens
+		ts.InputTokens += s.InputTokens
+		ts.OutputTokens += s.OutputTokens
+		ts.TotalDurationSec += s.SessionDurationSec
+
+		if toolFiles[s.Tool] == nil {
+			toolFiles[s.Tool] = make(map[string]struct{})
+		}
+		for f := range s.FilesWritten {
+			files[f] = struct{}{}
+			toolFiles[s.Tool][f] = struct{}{}
+		}
+		ts.UniqueFiles = len(toolFiles[s.Tool])
+		stats.ByTool[s.Tool] = ts
+
+		stats.Sessions++
+		stats.TotalTokens += s.TotalTokens
+		stats.InputTokens += s.InputTokens
+		stats.OutputTokens += s.OutputTokens
+		stats.TotalDurationSec += s.SessionDurationSec
+	}
+	stats.UniqueFiles = len(files)
+	return stats
+}
@@ -0,0 +1,57 @@
+package detector
+
+import "testing"
+
+func TestAggregateStats(t *testing.T) {
+	sessions := []*SessionInfo{
+		{
+			Tool:               ToolCodex,
+			FilesWritten:       map[string]struct{}{"a.go": {}, "b.go": {}},
+			TotalTokens:        1000,
+			InputTokens:        800,
+			OutputTokens:       200,
+			SessionDurationSec: 60,
+		},
+		{
+			Tool:               ToolCodex,
+			FilesWritten:       map[string]struct{}{"b.go": {}, "c.go": {}},
+			TotalTokens:        500,
+			SessionDurationSec: 30,
+		},
+		{
+			Tool:               ToolClaudeCode,
+			FilesWritten:       map[string]struct{}{"a.go": {}},
+			TotalTokens:        2000,
+			InputTokens:        1500,
+			OutputTokens:       500,
+			SessionDurationSec: 120,
+		},
+	}
+
+	stats := AggregateStats(sessions)
+	if stats.Sessions != 3 || stats.UniqueFiles != 3 {
+		t.Errorf("got %d sessions, %d files; want 3 and 3", stats.Sessions, stats.UniqueFiles)
+	}
+	if stats.TotalTokens != 3500 || stats.InputTokens != 2300 || stats.OutputTokens != 700 {
+		t.Errorf("tokens: got %d (%d/%d)", stats.TotalTokens, stats.InputTokens, stats.OutputTokens)
+	}
+	if stats.TotalDurationSec != 210 {
+		t.Errorf("duration: got %d, want 210", stats.TotalDurationSec)
+	}
+
+	codex := stats.ByTool[ToolCodex]
+	if codex.Sessions != 2 || codex.UniqueFiles != 3 || codex.TotalTokens != 1500 || codex.TotalDurationSec != 90 {
+		t.Errorf("codex: got %+v", codex)
+	}
+	claude := stats.ByTool[ToolClaudeCode]
+	if claude.Sessions != 1 || claude.UniqueFiles != 1 || claude.InputTokens != 1500 {
+		t.Errorf("claude: got %+v", claude)
+	}
+}
+
+func TestAggregateStats_Empty(t *testing.T) {
+	stats := AggregateStats(nil)
+	if stats.Sessions != 0 || stats.UniqueFiles != 0 || len(stats.ByTool) != 0 {
+		t.Errorf("got %+v, want zero stats", stats)
+	}
+}
@@ -0,0 +1,56 @@
+package detector
+
+import "time"
+
+// defaultWriteBucket is the WriteTimeline bucket size.
+const defaultWriteBucket = time.Minute
+
+// TimeBucket counts the file writes that happened in [Start, Start+size).
+type TimeBucket struct {
+	Start  time.Time
+	Writes int
+}
+
+// WriteTimeline returns the session's file writes counted per minute. See
+// WriteTimelineBy.
+func (s *SessionInfo) WriteTimeline() []TimeBucket {
+	return s.WriteTimelineBy(defaultWriteBucket)
+}
+
+// WriteTimelineBy groups the session's create and write Events into
+// consecutive buckets of the given size, aligned to multiples of size
+// since the zero time, from the first write's bucket to the last one's.
+// Buckets without writes are included so the result can be charted
+// directly. Deletes and events without a timestamp are ignored; repeated
+// writes to a file collapsed into one event count once. A size of zero or
+// less means one minute. Returns nil when there are no timed writes.
+func (s *SessionInfo) WriteTimelineBy(size time.Duration) []TimeBucket {
+	if size <= 0 {
+		size = defaultWriteBucket
+	}
+
+	var first, last time.Time
+	counts := make(map[time.Time]int)
+	for _, e := range s.Events {
+		if e.Kind == FileEventDelete || e.Time.IsZero() {
+			continue
+		}
+		start := e.Time.Truncate(size)
+		counts[start]++
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	var buckets []TimeBucket
+	for t := first; !t.After(last); t = t.Add(size) {
+		buckets = append(buckets, TimeBucket{Start: t, Writes: counts[t]})
+	}
+	return buckets
+}
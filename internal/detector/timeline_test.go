@@ -0,0 +1,44 @@
+package detector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteTimeline(t *testing.T) {
+	start := time.Date(2026, 2, 10, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	s := &SessionInfo{
+		Events: []FileEvent{
+			{Path: "a.go", Time: at(10 * time.Second), Kind: FileEventCreate},
+			{Path: "b.go", Time: at(50 * time.Second), Kind: FileEventCreate},
+			{Path: "a.go", Time: at(70 * time.Second), Kind: FileEventWrite},
+			{Path: "b.go", Time: at(3*time.Minute + 5*time.Second), Kind: FileEventDelete},
+			{Path: "c.go", Time: at(4*time.Minute + 59*time.Second), Kind: FileEventWrite},
+			{Path: "d.go", Kind: FileEventWrite},
+		},
+	}
+
+	got := s.WriteTimeline()
+	want := []int{2, 1, 0, 0, 1}
+	if len(got) != len(want) {
+		t.Fatalf("got %d buckets, want %d: %+v", len(got), len(want), got)
+	}
+	for i, b := range got {
+		if !b.Start.Equal(at(time.Duration(i) * time.Minute)) {
+			t.Errorf("bucket %d: start %v", i, b.Start)
+		}
+		if b.Writes != want[i] {
+			t.Errorf("bucket %d: got %d writes, want %d", i, b.Writes, want[i])
+		}
+	}
+
+	coarse := s.WriteTimelineBy(5 * time.Minute)
+	if len(coarse) != 1 || coarse[0].Writes != 4 {
+		t.Errorf("5m buckets: got %+v, want one bucket of 4", coarse)
+	}
+
+	if got := (&SessionInfo{}).WriteTimeline(); got != nil {
+		t.Errorf("no events: got %+v", got)
+	}
+}
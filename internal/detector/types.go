@@ -1,5 +1,12 @@
 package detector
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
 // Confidence levels for AI tool detection.
 type Confidence string
 
@@ -26,8 +33,19 @@ const (
 	ToolCursor     Tool = "cursor"
 	ToolCopilot    Tool = "copilot"
 	ToolCodex      Tool = "codex"
+	ToolWindsurf   Tool = "windsurf"
+	ToolCline      Tool = "cline"
+	ToolZed        Tool = "zed"
+	ToolAmazonQ    Tool = "amazon-q"
+	ToolJunie      Tool = "junie"
 )
 
+// String returns the tool's canonical name, as used in JSON payloads and
+// accepted by ParseTool.
+func (t Tool) String() string {
+	return string(t)
+}
+
 // Detection represents a single AI tool detection for a commit.
 type Detection struct {
 	Tool               Tool       `json:"tool"`
@@ -53,8 +71,167 @@ type Attribution struct {
 // SessionInfo holds metadata extracted from an AI tool session.
 type SessionInfo struct {
 	Tool               Tool
+	SessionID          string // tool-assigned ID, shared by resumed sessions
+	CWD                string // working directory the session ran in, where recorded
+	Originator         string // client that started the session, e.g. "codex_cli" or an IDE extension; Codex only
+	Source             string // how the session was launched, e.g. "cli" or "vscode"; Codex only
 	FilesWritten       map[string]struct{}
+	DirsWritten        map[string]struct{}   // bulk writes whose files can't be enumerated
+	FilesRead          map[string]struct{}   // inputs the session read, e.g. rsync manifests
+	FilesRenamedFrom   map[string]struct{}   // old paths of files the session renamed; the new paths are in FilesWritten
+	DirectoriesTouched int                   // distinct parent directories of FilesWritten; set by the Codex detector
+	FileActions        map[string]FileAction // how a written file was changed, where the command tells
+	GitActions         []string              // git commands that move changes without editing, e.g. "git stash pop"
+	FailedCommands     int                   // shell commands that exited non-zero, where the tool records exit status
+	ExecDuration       time.Duration         // total time spent running shell commands, where recorded
+	RanTests           bool                  // a shell command ran a test suite, e.g. go test
+	Interrupted        bool                  // the last task was aborted or died with an error, so its edits may be incomplete
+	EndReason          string                // how the last task ended: "completed", "error" or the abort reason, e.g. "interrupted"; empty when unknown
+	LinesAdded         int                   // lines added by patch hunks; shell writes contribute 0
+	LinesRemoved       int                   // lines removed by patch hunks; shell writes contribute 0
+	NetLineDelta       int64                 // LinesAdded - LinesRemoved
 	Model              string
+	Provider           string // model vendor recorded by the tool, e.g. "openai"
 	TotalTokens        int64
+	InputTokens        int64 // prompt-side share of TotalTokens, where the tool reports it
+	OutputTokens       int64 // completion-side share of TotalTokens, where the tool reports it
 	SessionDurationSec int64
+	StartedAt          time.Time
+	EndedAt            time.Time
+	Turns              []Turn
+	Events             []FileEvent         // file changes in the order they happened, where the tool timestamps them
+	Errors             []SessionError      // session files that failed to parse during merging
+	SourceFiles        []string            // session files merged into this one; only with DetectorConfig.Provenance
+	FileSources        map[string][]string // session files that wrote each file; only with DetectorConfig.Provenance
+}
+
+// SessionError records a session file that failed to parse.
+type SessionError struct {
+	Path string
+	Err  error
+}
+
+func (e SessionError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e SessionError) Unwrap() error {
+	return e.Err
+}
+
+// FileAction describes how a session changed a written file.
+type FileAction string
+
+const (
+	FileActionCreate    FileAction = "create"    // file did not exist before the write
+	FileActionModify    FileAction = "modify"    // existing file partially rewritten in place
+	FileActionOverwrite FileAction = "overwrite" // existing file truncated and replaced
+)
+
+// FileEventKind classifies a FileEvent.
+type FileEventKind string
+
+const (
+	FileEventCreate FileEventKind = "create" // file did not exist before the write
+	FileEventWrite  FileEventKind = "write"  // existing file written
+	FileEventDelete FileEventKind = "delete" // file removed
+)
+
+// FileEvent records a single change to a file. Consecutive events with the
+// same path and kind are collapsed into the first of them.
+type FileEvent struct {
+	Path string
+	Time time.Time
+	Kind FileEventKind
+}
+
+// Turn records the files written during a single model turn together with
+// the tokens that turn consumed.
+type Turn struct {
+	FilesWritten []string
+	Tokens       int64
+}
+
+// TokensPerFile approximates the tokens spent on each written file by
+// splitting every turn's token usage evenly across the files it wrote.
+// Files written outside a turn with recorded usage are not included.
+func (s *SessionInfo) TokensPerFile() map[string]int64 {
+	result := make(map[string]int64)
+	for _, turn := range s.Turns {
+		n := int64(len(turn.FilesWritten))
+		if n == 0 {
+			continue
+		}
+		files := append([]string(nil), turn.FilesWritten...)
+		sort.Strings(files)
+		share, rem := turn.Tokens/n, turn.Tokens%n
+		for i, f := range files {
+			result[f] += share
+			// Hand out the remainder one token at a time so turn totals are preserved
+			if int64(i) < rem {
+				result[f]++
+			}
+		}
+	}
+	return result
+}
+
+// AvgTokensPerFile is TotalTokens divided by the number of files written,
+// or 0 when no file was written. Unlike TokensPerFile it needs no per-turn
+// usage, so it works for every tool that reports tokens.
+func (s *SessionInfo) AvgTokensPerFile() float64 {
+	if len(s.FilesWritten) == 0 {
+		return 0
+	}
+	return float64(s.TotalTokens) / float64(len(s.FilesWritten))
+}
+
+// TokensPerMinute is TotalTokens divided by the session duration in
+// minutes, or 0 when the duration is unknown.
+func (s *SessionInfo) TokensPerMinute() float64 {
+	if s.SessionDurationSec <= 0 {
+		return 0
+	}
+	return float64(s.TotalTokens) / (float64(s.SessionDurationSec) / 60)
+}
+
+// Summary returns a one-line description of the session for terminal
+// output, e.g. "codex · gpt-5.3-codex · 3 files · 18.5k tokens · 1m32s".
+// The model, tokens and duration are left out when unknown.
+func (s *SessionInfo) Summary() string {
+	var parts []string
+	if s.Tool != "" {
+		parts = append(parts, string(s.Tool))
+	}
+	if s.Model != "" {
+		parts = append(parts, s.Model)
+	}
+	if n := len(s.FilesWritten); n == 1 {
+		parts = append(parts, "1 file")
+	} else {
+		parts = append(parts, fmt.Sprintf("%d files", n))
+	}
+	if s.TotalTokens > 0 {
+		parts = append(parts, abbreviateCount(s.TotalTokens)+" tokens")
+	}
+	if s.SessionDurationSec > 0 {
+		parts = append(parts, (time.Duration(s.SessionDurationSec) * time.Second).String())
+	}
+	return strings.Join(parts, " · ")
+}
+
+// abbreviateCount formats n with a k or M suffix and one decimal, so
+// 18500 is "18.5k" and 2000000 is "2M". Counts below 1000 are unchanged.
+func abbreviateCount(n int64) string {
+	var v float64
+	var suffix string
+	switch {
+	case n < 1000:
+		return fmt.Sprint(n)
+	case n < 999_950:
+		v, suffix = float64(n)/1e3, "k"
+	default:
+		v, suffix = float64(n)/1e6, "M"
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", v), ".0") + suffix
 }
@@ -0,0 +1,344 @@
+package detector
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// watchInterval is how often WatchSessions polls the session stores.
+var watchInterval = 2 * time.Second
+
+// watchSource is a session store polled by WatchSessions.
+type watchSource struct {
+	globs []string
+	parse func(path string) (*SessionInfo, error)
+}
+
+// watchSources returns the per-file session stores for repoRoot: Claude
+// Code's project directory and Codex's rollout tree under
+// cfg.SessionsDir.
+func watchSources(ctx context.Context, repoRoot string, cfg DetectorConfig) []watchSource {
+	var sources []watchSource
+	if dir := claudeSessionDir(repoRoot); dir != "" {
+		sources = append(sources, watchSource{
+			globs: []string{filepath.Join(dir, "*.jsonl")},
+			parse: func(path string) (*SessionInfo, error) {
+				return parseClaudeSession(path, repoRoot)
+			},
+		})
+	}
+	if sessionsDir := cfg.codexSessionsDir(); sessionsDir != "" {
+		sources = append(sources, watchSource{
+			globs: codexRolloutGlobs(sessionsDir),
+			parse: func(path string) (*SessionInfo, error) {
+				return parseCodexSessionFor(ctx, path, repoRoot, cfg)
+			},
+		})
+	}
+	return sources
+}
+
+// WatchSessions polls the session stores for repoRoot and calls fn with
+// every session file that is created or modified after the watch starts
+// and wrote files in the repo. Files that exist when it starts are not
+// delivered until they change. Codex rollouts are looked for in
+// cfg.SessionsDir and parsed with cfg. It blocks until ctx is cancelled
+// and then returns ctx.Err().
+func WatchSessions(ctx context.Context, repoRoot string, cfg DetectorConfig, fn func(*SessionInfo)) error {
+	sources := watchSources(ctx, repoRoot, cfg)
+	seen := make(map[string]time.Time)
+	scan := func(deliver bool) {
+		for _, src := range sources {
+			var paths []string
+			for _, glob := range src.globs {
+				m, _ := filepath.Glob(glob)
+				paths = append(paths, m...)
+			}
+			for _, path := range paths {
+				st, err := os.Stat(path)
+				if err != nil || st.IsDir() {
+					continue
+				}
+				if prev, ok := seen[path]; ok && !st.ModTime().After(prev) {
+					continue
+				}
+				seen[path] = st.ModTime()
+				if !deliver {
+					continue
+				}
+				if info, err := src.parse(path); err == nil && info != nil {
+					fn(info)
+				}
+			}
+		}
+	}
+
+	scan(false)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			scan(true)
+		}
+	}
+}
+
+// WatchSession follows the newest Codex rollout for repoRoot in
+// cfg.SessionsDir and sends the session to out each time lines are
+// appended to it. The rollout found when the watch starts is tailed from
+// its end: only its session_meta is read, so the session reports what
+// happens from then on. When a newer rollout for the repo appears, the
+// watch switches to it and reads it from the top, since all of it is new.
+// Sessions that haven't written files yet are not sent. It blocks until
+// ctx is cancelled and then returns ctx.Err().
+func WatchSession(ctx context.Context, repoRoot string, cfg DetectorConfig, out chan<- *SessionInfo) error {
+	w := &rolloutWatch{
+		sessionsDir: cfg.codexSessionsDir(),
+		repoRoot:    repoRoot,
+		cfg:         cfg,
+		matched:     make(map[string]bool),
+	}
+	if path := w.newest(w.discover(ctx)); path != "" {
+		w.tail = newRolloutTailAtEnd(path, cfg)
+	}
+
+	poll := func() bool {
+		if path := w.newest(w.recent()); path != "" && (w.tail == nil || path != w.tail.path) {
+			w.tail = newRolloutTail(path, cfg)
+		}
+		if w.tail == nil {
+			return true
+		}
+		if changed, err := w.tail.read(); err != nil || !changed {
+			return true
+		}
+		info := w.tail.parser.session()
+		if info == nil {
+			return true
+		}
+		select {
+		case out <- info.clone():
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for poll() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return ctx.Err()
+}
+
+// rolloutWatch tracks the rollout WatchSession follows between polls.
+type rolloutWatch struct {
+	sessionsDir string
+	repoRoot    string
+	cfg         DetectorConfig
+	matched     map[string]bool // whether each rollout checked ran in repoRoot
+	tail        *rolloutTail
+}
+
+// discover returns every recent rollout under the sessions directory. It
+// walks the whole tree, so it only runs when the watch starts.
+func (w *rolloutWatch) discover(ctx context.Context) []string {
+	paths, _ := findCodexSessionsIn(ctx, w.sessionsDir, sessionMaxAge())
+	return paths
+}
+
+// recent returns the rollouts a poll considers: the followed one and those
+// in the directories new rollouts are written to, today's
+// YYYY/MM/DD directory (by local and UTC date) and the followed rollout's
+// own directory.
+func (w *rolloutWatch) recent() []string {
+	now := time.Now()
+	dirs := []string{
+		filepath.Join(w.sessionsDir, now.Format("2006/01/02")),
+		filepath.Join(w.sessionsDir, now.UTC().Format("2006/01/02")),
+	}
+	var paths []string
+	if w.tail != nil {
+		dirs = append(dirs, filepath.Dir(w.tail.path))
+		paths = append(paths, w.tail.path)
+	}
+	for _, dir := range appendMissing(nil, dirs...) {
+		m, _ := filepath.Glob(filepath.Join(dir, "rollout-*.jsonl"))
+		paths = appendMissing(paths, m...)
+	}
+	return paths
+}
+
+// newest returns the most recently modified uncompressed rollout among
+// paths whose session ran in the repo, or "" when there is none. The repo
+// check is done once per path; rollouts whose session_meta hasn't been
+// written yet are checked again on the next call.
+func (w *rolloutWatch) newest(paths []string) string {
+	var newest string
+	var newestMod time.Time
+	var buf []byte
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".gz") {
+			continue
+		}
+		ok, known := w.matched[path]
+		if !known {
+			if buf == nil {
+				buf = make([]byte, 0, 64*1024)
+			}
+			cwd := codexRolloutCWD(path, buf)
+			if cwd == "" {
+				continue
+			}
+			ok = cwdMatches(cwd, w.repoRoot, w.cfg.MatchSubdirs)
+			w.matched[path] = ok
+		}
+		if !ok {
+			continue
+		}
+		st, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if newest == "" || st.ModTime().After(newestMod) {
+			newest, newestMod = path, st.ModTime()
+		}
+	}
+	return newest
+}
+
+// codexRolloutCWD returns the cwd recorded by a rollout's session_meta, or
+// "" when it isn't within the first codexMetaScanLines lines. buf is the
+// initial line buffer, so callers checking many rollouts can share one.
+// Compressed rollouts are decompressed while reading.
+func codexRolloutCWD(path string, buf []byte) string {
+	var line codexLine
+	if err := json.Unmarshal(codexRolloutMeta(path, buf), &line); err != nil {
+		return ""
+	}
+	var meta codexSessionMeta
+	if err := json.Unmarshal(line.Payload, &meta); err != nil {
+		return ""
+	}
+	return meta.CWD
+}
+
+// codexRolloutMeta returns the line holding a rollout's session_meta
+// record, or nil when it isn't within the first codexMetaScanLines lines.
+// buf is as for codexRolloutCWD.
+func codexRolloutMeta(path string, buf []byte) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(buf, 10*1024*1024)
+	for i := 0; i < codexMetaScanLines && scanner.Scan(); i++ {
+		var line codexLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err == nil && line.Type == "session_meta" {
+			return append([]byte(nil), scanner.Bytes()...)
+		}
+	}
+	return nil
+}
+
+// rolloutTail incrementally parses a rollout that is still being written.
+type rolloutTail struct {
+	path    string
+	cfg     DetectorConfig
+	offset  int64  // bytes of the file consumed so far
+	partial []byte // trailing line not yet terminated by a newline
+	parser  *codexRolloutParser
+}
+
+func newRolloutTail(path string, cfg DetectorConfig) *rolloutTail {
+	return &rolloutTail{path: path, cfg: cfg, parser: newCodexRolloutParser(nil, cfg)}
+}
+
+// newRolloutTailAtEnd returns a tail that skips what path already holds.
+// Only the session_meta record is parsed, so paths still resolve against
+// the session's cwd.
+func newRolloutTailAtEnd(path string, cfg DetectorConfig) *rolloutTail {
+	t := newRolloutTail(path, cfg)
+	if meta := codexRolloutMeta(path, nil); meta != nil {
+		t.parser.parseLine(meta)
+	}
+	if st, err := os.Stat(path); err == nil {
+		t.offset = st.Size()
+	}
+	return t
+}
+
+// read parses the complete lines appended since the last call and reports
+// whether there were any. A file that shrank was replaced, so it is parsed
+// again from the top.
+func (t *rolloutTail) read() (bool, error) {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if st.Size() < t.offset {
+		*t = *newRolloutTail(t.path, t.cfg)
+	}
+	if st.Size() == t.offset {
+		return false, nil
+	}
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return false, err
+	}
+	t.offset += int64(len(data))
+
+	data = append(t.partial, data...)
+	parsed := false
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if line := data[:i]; len(bytes.TrimSpace(line)) > 0 {
+			t.parser.parseLine(line)
+			parsed = true
+		}
+		data = data[i+1:]
+	}
+	t.partial = append([]byte(nil), data...)
+	return parsed, nil
+}
@@ -0,0 +1,179 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchSessions(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	defer func(d time.Duration) { watchInterval = d }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	sessionDir := filepath.Join(homeDir, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	rollout := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}
+` + testCodexTouchLine
+
+	// A session present before the watch starts is not delivered
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-old.jsonl"), []byte(rollout), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got := make(chan *SessionInfo, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchSessions(ctx, testRepoRoot, DetectorConfig{}, func(s *SessionInfo) { got <- s })
+	}()
+
+	// Give the watcher time to record the existing files
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-new.jsonl"), []byte(rollout), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case s := <-got:
+		if s.Tool != ToolCodex {
+			t.Errorf("tool: got %q, want %q", s.Tool, ToolCodex)
+		}
+		if files := sortedKeys(s.FilesWritten); !equal(files, []string{"a.go"}) {
+			t.Errorf("files: got %v", files)
+		}
+	case <-ctx.Done():
+		t.Fatal("callback did not fire for the new session")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected only the new session, got %d more", len(got))
+	}
+}
+
+func TestWatchSession(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	defer func(d time.Duration) { watchInterval = d }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	// A configured sessions directory outside the Codex home
+	sessionsDir := filepath.Join(t.TempDir(), "sessions")
+	sessionDir := filepath.Join(sessionsDir, "2026", "02", "10")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	meta := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}` + "\n"
+	touch := func(name string) string {
+		return `{"timestamp":"2026-02-10T10:26:00.000Z","type":"response_item","payload":{"type":"function_call","name":"exec_command","arguments":"{\"cmd\":\"touch ` + name + `\"}"}}` + "\n"
+	}
+	first := filepath.Join(sessionDir, "rollout-first.jsonl")
+	if err := os.WriteFile(first, []byte(meta+touch("a.go")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/other"}}` + "\n" + touch("x.go")
+	if err := os.WriteFile(filepath.Join(sessionDir, "rollout-other.jsonl"), []byte(other), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out := make(chan *SessionInfo)
+	done := make(chan error, 1)
+	go func() { done <- WatchSession(ctx, testRepoRoot, DetectorConfig{SessionsDir: sessionsDir}, out) }()
+
+	expect := func(want ...string) {
+		t.Helper()
+		select {
+		case s := <-out:
+			if files := sortedKeys(s.FilesWritten); !equal(files, want) {
+				t.Errorf("files: got %v, want %v", files, want)
+			}
+			if s.CWD != testRepoRoot {
+				t.Errorf("cwd: got %q, want %q", s.CWD, testRepoRoot)
+			}
+		case <-ctx.Done():
+			t.Fatalf("no session sent, want %v", want)
+		}
+	}
+
+	// The rollout found at the start is tailed from its end, so a.go
+	// isn't reported
+	time.Sleep(100 * time.Millisecond)
+	f, err := os.OpenFile(first, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(touch("b.go")); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	expect("b.go")
+
+	// A newer rollout for the repo in today's directory takes over and is
+	// read from the top
+	todayDir := filepath.Join(sessionsDir, time.Now().Format("2006/01/02"))
+	if err := os.MkdirAll(todayDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	second := filepath.Join(todayDir, "rollout-second.jsonl")
+	if err := os.WriteFile(second, []byte(meta+touch("c.go")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(second, later, later); err != nil {
+		t.Fatal(err)
+	}
+	expect("c.go")
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestRolloutTail_PartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollout.jsonl")
+	line := `{"timestamp":"2026-02-10T10:25:57.694Z","type":"session_meta","payload":{"cwd":"/Users/jose/myproject"}}`
+	if err := os.WriteFile(path, []byte(line[:40]), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tail := newRolloutTail(path, DetectorConfig{})
+	if parsed, err := tail.read(); err != nil || parsed {
+		t.Fatalf("partial line: got parsed=%v, err=%v", parsed, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(line[40:] + "\n" + testCodexTouchLine + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if parsed, err := tail.read(); err != nil || !parsed {
+		t.Fatalf("completed lines: got parsed=%v, err=%v", parsed, err)
+	}
+	info := tail.parser.session()
+	if info == nil || info.CWD != testRepoRoot {
+		t.Fatalf("got %+v, want the session from the joined line", info)
+	}
+	if parsed, _ := tail.read(); parsed {
+		t.Error("expected nothing new on an unchanged file")
+	}
+}
@@ -0,0 +1,171 @@
+package detector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Windsurf Cascade session detection.
+//
+// Windsurf is a VS Code fork, so workspaces are mapped the same way:
+//   macOS:  ~/Library/Application Support/Windsurf/User/workspaceStorage/{hash}/workspace.json
+//   Linux:  ~/.config/Windsurf/User/workspaceStorage/{hash}/workspace.json
+//   → {"folder": "file:///path/to/repo"}
+//
+// Cascade trajectories for the workspace are stored alongside it:
+//   {hash}/cascade/{trajectoryId}.json
+//
+// Applied edits appear as steps of type "code_action" whose codeAction.uri
+// is the edited file's file:// URI. Rejected actions are ignored.
+
+type windsurfTrajectory struct {
+	Model string         `json:"model"`
+	Steps []windsurfStep `json:"steps"`
+}
+
+type windsurfStep struct {
+	Type       string              `json:"type"`
+	Timestamp  int64               `json:"timestamp"` // epoch ms
+	CodeAction *windsurfCodeAction `json:"codeAction,omitempty"`
+	Usage      *windsurfUsage      `json:"usage,omitempty"`
+}
+
+type windsurfCodeAction struct {
+	URI    string `json:"uri"`
+	Status string `json:"status"` // "applied", "rejected", "pending"
+}
+
+type windsurfUsage struct {
+	InputTokens  int64 `json:"inputTokens"`
+	OutputTokens int64 `json:"outputTokens"`
+}
+
+// windsurfBaseDirs returns the Windsurf workspace storage base directories
+// for the current OS.
+func windsurfBaseDirs() []string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{
+			filepath.Join(homeDir, "Library", "Application Support", "Windsurf", "User", "workspaceStorage"),
+		}
+	case "linux":
+		return []string{
+			filepath.Join(homeDir, ".config", "Windsurf", "User", "workspaceStorage"),
+		}
+	}
+	return nil
+}
+
+// findWindsurfSessions finds recent Cascade trajectory files in the workspace dir.
+func findWindsurfSessions(workspaceDir string, maxAge time.Duration) []string {
+	cascadeDir := filepath.Join(workspaceDir, "cascade")
+	entries, err := os.ReadDir(cascadeDir)
+	if err != nil {
+		return nil
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	var sessions []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().Before(cutoff) {
+			continue
+		}
+		sessions = append(sessions, filepath.Join(cascadeDir, entry.Name()))
+	}
+	return sessions
+}
+
+// parseWindsurfSession reads a Cascade trajectory file and extracts the
+// files written by applied code actions within repoRoot.
+func parseWindsurfSession(jsonPath string, repoRoot string) (*SessionInfo, error) {
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var traj windsurfTrajectory
+	if err := json.Unmarshal(data, &traj); err != nil {
+		return nil, nil
+	}
+
+	info := &SessionInfo{
+		Tool:         ToolWindsurf,
+		FilesWritten: make(map[string]struct{}),
+		Model:        traj.Model,
+	}
+
+	var firstTimestamp, lastTimestamp int64
+	for _, step := range traj.Steps {
+		if step.Timestamp > 0 {
+			if firstTimestamp == 0 || step.Timestamp < firstTimestamp {
+				firstTimestamp = step.Timestamp
+			}
+			if step.Timestamp > lastTimestamp {
+				lastTimestamp = step.Timestamp
+			}
+		}
+
+		if step.Usage != nil {
+			info.TotalTokens += step.Usage.InputTokens + step.Usage.OutputTokens
+			info.InputTokens += step.Usage.InputTokens
+			info.OutputTokens += step.Usage.OutputTokens
+		}
+
+		if step.Type != "code_action" || step.CodeAction == nil {
+			continue
+		}
+		if step.CodeAction.Status != "applied" {
+			continue
+		}
+		absPath := uriToPath(step.CodeAction.URI)
+		relPath := strings.TrimPrefix(absPath, repoRoot+"/")
+		if relPath != absPath && relPath != "" {
+			info.FilesWritten[relPath] = struct{}{}
+		}
+	}
+
+	if len(info.FilesWritten) == 0 {
+		return nil, nil
+	}
+
+	if firstTimestamp > 0 && lastTimestamp > 0 {
+		info.SessionDurationSec = (lastTimestamp - firstTimestamp) / 1000
+		info.StartedAt = time.UnixMilli(firstTimestamp)
+		info.EndedAt = time.UnixMilli(lastTimestamp)
+	}
+
+	return info, nil
+}
+
+// detectWindsurf finds recent Cascade sessions for the repo and merges
+// them, summing their tokens and durations. Returns nil if Windsurf isn't
+// installed.
+func detectWindsurf(repoRoot string, maxAge time.Duration) (*SessionInfo, error) {
+	workspaceDir := findWorkspaceDir(windsurfBaseDirs(), repoRoot)
+	if workspaceDir == "" {
+		return nil, nil
+	}
+
+	var sessions []*SessionInfo
+	for _, path := range findWindsurfSessions(workspaceDir, maxAge) {
+		session, err := parseWindsurfSession(path, repoRoot)
+		if err != nil || session == nil {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return mergeSessions(sessions, usageSum), nil
+}
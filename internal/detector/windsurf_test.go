@@ -0,0 +1,123 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// testWindsurfWorkspaceStorage returns the platform-correct Windsurf workspace
+// storage base dir under the given home directory (mirrors windsurfBaseDirs).
+func testWindsurfWorkspaceStorage(homeDir string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support", "Windsurf", "User", "workspaceStorage")
+	default:
+		return filepath.Join(homeDir, ".config", "Windsurf", "User", "workspaceStorage")
+	}
+}
+
+const testWindsurfTrajectory = `{
+  "model": "claude-sonnet-4",
+  "steps": [
+    {"type": "user_input", "timestamp": 1770717600000},
+    {"type": "code_action", "timestamp": 1770717630000, "codeAction": {"uri": "file:///Users/jose/myproject/src/main.go", "status": "applied"}, "usage": {"inputTokens": 1200, "outputTokens": 300}},
+    {"type": "code_action", "timestamp": 1770717660000, "codeAction": {"uri": "file:///Users/jose/myproject/src/skip.go", "status": "rejected"}},
+    {"type": "code_action", "timestamp": 1770717720000, "codeAction": {"uri": "file:///Users/jose/other/x.go", "status": "applied"}, "usage": {"inputTokens": 100, "outputTokens": 50}}
+  ]
+}`
+
+func TestParseWindsurfSession_Basic(t *testing.T) {
+	path := writeCopilotTestJSON(t, testWindsurfTrajectory)
+	info, err := parseWindsurfSession(path, testRepoRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil session info")
+	}
+
+	// Rejected actions and files outside the repo are excluded
+	wantFiles := []string{"src/main.go"}
+	if got := sortedKeys(info.FilesWritten); !equal(got, wantFiles) {
+		t.Errorf("files: got %v, want %v", got, wantFiles)
+	}
+	if info.Model != "claude-sonnet-4" {
+		t.Errorf("model: got %q", info.Model)
+	}
+	if info.TotalTokens != 1650 {
+		t.Errorf("tokens: got %d, want 1650", info.TotalTokens)
+	}
+	if info.SessionDurationSec != 120 {
+		t.Errorf("duration: got %d, want 120", info.SessionDurationSec)
+	}
+	if info.Tool != ToolWindsurf {
+		t.Errorf("tool: got %q", info.Tool)
+	}
+}
+
+func TestParseWindsurfSession_MalformedJSON(t *testing.T) {
+	path := writeCopilotTestJSON(t, `{not json`)
+	info, err := parseWindsurfSession(path, testRepoRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info != nil {
+		t.Errorf("expected nil for malformed JSON, got %+v", info)
+	}
+}
+
+func TestDetectWindsurf_Integration(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	wsDir := filepath.Join(testWindsurfWorkspaceStorage(homeDir), "abc123")
+	if err := os.MkdirAll(filepath.Join(wsDir, "cascade"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	wsJSON := `{"folder": "file://` + testRepoRoot + `"}`
+	if err := os.WriteFile(filepath.Join(wsDir, "workspace.json"), []byte(wsJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"t1.json", "t2.json"} {
+		if err := os.WriteFile(filepath.Join(wsDir, "cascade", name), []byte(testWindsurfTrajectory), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := detectWindsurf(testRepoRoot, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"src/main.go"}) {
+		t.Errorf("files: got %v", got)
+	}
+	// Sessions are independent, so usage is summed
+	if info.TotalTokens != 3300 || info.SessionDurationSec != 240 {
+		t.Errorf("usage: got %d tokens over %ds, want 3300 over 240s", info.TotalTokens, info.SessionDurationSec)
+	}
+	if want := time.UnixMilli(1770717600000); !info.StartedAt.Equal(want) {
+		t.Errorf("started: got %v, want %v", info.StartedAt, want)
+	}
+	if info.Tool != ToolWindsurf {
+		t.Errorf("tool: got %q", info.Tool)
+	}
+}
+
+func TestDetectWindsurf_NotInstalled(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	info, err := detectWindsurf(testRepoRoot, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info != nil {
+		t.Errorf("expected nil when Windsurf isn't installed, got %+v", info)
+	}
+}
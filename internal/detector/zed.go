@@ -0,0 +1,213 @@
+package detector
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Zed agent thread detection.
+//
+// Zed keeps agent panel threads in a SQLite database:
+//   macOS:  ~/Library/Application Support/Zed/threads/threads.db
+//   Linux:  $XDG_DATA_HOME/zed/threads/threads.db (default ~/.local/share)
+//   → table threads(id, summary, updated_at, data_type, data)
+//
+// data is the serialized thread, stored as JSON when data_type is "json".
+// Newer Zed versions compress it with zstd (data_type "zstd"); those rows
+// are skipped, since decoding them would need a zstd dependency.
+//
+// The thread's initial_project_snapshot lists the worktrees it ran in, and
+// edits appear as edit_file/create_file tool uses whose input.path is
+// prefixed with the worktree's root directory name:
+//   {"name": "edit_file", "input": {"path": "myproject/src/main.rs", ...}}
+// An edit counts once its tool result comes back without an error.
+//
+// As with Cursor, the sqlite3 CLI is used rather than a Go SQLite driver.
+
+// zedWriteTools are the tool names that write files.
+var zedWriteTools = map[string]bool{
+	"edit_file":         true,
+	"create_file":       true,
+	"find_replace_file": true,
+}
+
+type zedThread struct {
+	UpdatedAt              string              `json:"updated_at"`
+	Messages               []zedMessage        `json:"messages"`
+	InitialProjectSnapshot *zedProjectSnapshot `json:"initial_project_snapshot"`
+	CumulativeTokenUsage   zedTokenUsage       `json:"cumulative_token_usage"`
+	Model                  *zedModel           `json:"model"`
+}
+
+type zedProjectSnapshot struct {
+	WorktreeSnapshots []zedWorktreeSnapshot `json:"worktree_snapshots"`
+	Timestamp         string                `json:"timestamp"`
+}
+
+type zedWorktreeSnapshot struct {
+	WorktreePath string `json:"worktree_path"`
+}
+
+type zedMessage struct {
+	Role        string          `json:"role"`
+	ToolUses    []zedToolUse    `json:"tool_uses"`
+	ToolResults []zedToolResult `json:"tool_results"`
+}
+
+type zedToolUse struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Input struct {
+		Path string `json:"path"`
+	} `json:"input"`
+}
+
+type zedToolResult struct {
+	ToolUseID string `json:"tool_use_id"`
+	IsError   bool   `json:"is_error"`
+}
+
+type zedTokenUsage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+}
+
+type zedModel struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// zedThreadsDBPath returns the path to Zed's threads database for the
+// current OS, or "" when unsupported.
+func zedThreadsDBPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support", "Zed", "threads", "threads.db")
+	case "linux":
+		dataDir := os.Getenv("XDG_DATA_HOME")
+		if dataDir == "" {
+			dataDir = filepath.Join(homeDir, ".local", "share")
+		}
+		return filepath.Join(dataDir, "zed", "threads", "threads.db")
+	}
+	return ""
+}
+
+// parseZedThread extracts the files written by accepted edits in a thread,
+// if one of its worktrees is repoRoot. Returns nil for other repos.
+func parseZedThread(data []byte, repoRoot string) *SessionInfo {
+	var thread zedThread
+	if err := json.Unmarshal(data, &thread); err != nil || thread.InitialProjectSnapshot == nil {
+		return nil
+	}
+
+	inRepo := false
+	for _, wt := range thread.InitialProjectSnapshot.WorktreeSnapshots {
+		if wt.WorktreePath == repoRoot {
+			inRepo = true
+			break
+		}
+	}
+	if !inRepo {
+		return nil
+	}
+
+	info := &SessionInfo{
+		Tool:         ToolZed,
+		FilesWritten: make(map[string]struct{}),
+		InputTokens:  thread.CumulativeTokenUsage.InputTokens,
+		OutputTokens: thread.CumulativeTokenUsage.OutputTokens,
+		TotalTokens:  thread.CumulativeTokenUsage.InputTokens + thread.CumulativeTokenUsage.OutputTokens,
+	}
+	if thread.Model != nil {
+		info.Model = thread.Model.Model
+		info.Provider = thread.Model.Provider
+	}
+
+	// Tool results arrive in the message after the tool use
+	succeeded := make(map[string]bool)
+	for _, msg := range thread.Messages {
+		for _, r := range msg.ToolResults {
+			succeeded[r.ToolUseID] = !r.IsError
+		}
+	}
+
+	rootPrefix := filepath.Base(repoRoot) + "/"
+	for _, msg := range thread.Messages {
+		for _, use := range msg.ToolUses {
+			if !zedWriteTools[use.Name] || !succeeded[use.ID] {
+				continue
+			}
+			p := strings.TrimSpace(use.Input.Path)
+			if filepath.IsAbs(p) {
+				rel := strings.TrimPrefix(p, repoRoot+"/")
+				if rel == p {
+					continue
+				}
+				p = rel
+			} else {
+				p = strings.TrimPrefix(p, rootPrefix)
+			}
+			if p != "" {
+				info.FilesWritten[p] = struct{}{}
+			}
+		}
+	}
+	if len(info.FilesWritten) == 0 {
+		return nil
+	}
+
+	if t, ok := parseTimestamp(thread.InitialProjectSnapshot.Timestamp); ok {
+		info.StartedAt = t
+	}
+	if t, ok := parseTimestamp(thread.UpdatedAt); ok {
+		info.EndedAt = t
+	}
+	if !info.StartedAt.IsZero() && info.EndedAt.After(info.StartedAt) {
+		info.SessionDurationSec = int64(info.EndedAt.Sub(info.StartedAt).Seconds())
+	}
+	return info
+}
+
+// detectZed finds recent Zed agent threads run in repoRoot and merges their
+// file sets. Token counts and durations are summed and the most recently
+// updated thread's model is kept.
+func detectZed(repoRoot string, maxAge time.Duration) (*SessionInfo, error) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return nil, nil
+	}
+	dbPath := zedThreadsDBPath()
+	if dbPath == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, nil
+	}
+
+	values, err := sqliteQueryValues(dbPath,
+		`SELECT CAST(data AS TEXT) AS value FROM threads WHERE data_type = 'json' ORDER BY updated_at`)
+	if err != nil || len(values) == 0 {
+		return nil, nil
+	}
+
+	var threads []*SessionInfo
+	cutoff := time.Now().Add(-maxAge)
+	for _, v := range values {
+		thread := parseZedThread([]byte(v), repoRoot)
+		if thread == nil || (!thread.EndedAt.IsZero() && thread.EndedAt.Before(cutoff)) {
+			continue
+		}
+		threads = append(threads, thread)
+	}
+	return mergeSessions(threads, usageSum), nil
+}
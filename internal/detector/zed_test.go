@@ -0,0 +1,105 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testZedThread = `{
+  "updated_at": "2026-02-10T10:30:00Z",
+  "initial_project_snapshot": {
+    "worktree_snapshots": [{"worktree_path": "/Users/jose/myproject"}],
+    "timestamp": "2026-02-10T10:00:00Z"
+  },
+  "model": {"provider": "anthropic", "model": "claude-sonnet-4"},
+  "cumulative_token_usage": {"input_tokens": 1200, "output_tokens": 300},
+  "messages": [
+    {"role": "user", "tool_uses": [], "tool_results": []},
+    {"role": "assistant", "tool_uses": [
+      {"id": "t1", "name": "edit_file", "input": {"path": "myproject/src/main.rs", "mode": "edit"}},
+      {"id": "t2", "name": "edit_file", "input": {"path": "myproject/src/lib.rs", "mode": "create"}},
+      {"id": "t3", "name": "read_file", "input": {"path": "myproject/Cargo.toml"}},
+      {"id": "t4", "name": "edit_file", "input": {"path": "myproject/src/pending.rs"}}
+    ]},
+    {"role": "user", "tool_results": [
+      {"tool_use_id": "t1", "is_error": false},
+      {"tool_use_id": "t2", "is_error": true},
+      {"tool_use_id": "t3", "is_error": false}
+    ]}
+  ]
+}`
+
+func TestParseZedThread(t *testing.T) {
+	info := parseZedThread([]byte(testZedThread), testRepoRoot)
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"src/main.rs"}) {
+		t.Errorf("files: got %v, want only the succeeded edit", got)
+	}
+	if info.Model != "claude-sonnet-4" || info.Provider != "anthropic" {
+		t.Errorf("model: got %q/%q", info.Provider, info.Model)
+	}
+	if info.TotalTokens != 1500 || info.InputTokens != 1200 || info.OutputTokens != 300 {
+		t.Errorf("tokens: got %d (%d/%d)", info.TotalTokens, info.InputTokens, info.OutputTokens)
+	}
+	if info.SessionDurationSec != 1800 {
+		t.Errorf("duration: got %d, want 1800", info.SessionDurationSec)
+	}
+}
+
+func TestParseZedThread_OtherRepo(t *testing.T) {
+	if info := parseZedThread([]byte(testZedThread), "/Users/jose/other"); info != nil {
+		t.Errorf("expected nil for another repo, got %+v", info)
+	}
+}
+
+func TestDetectZed(t *testing.T) {
+	skipIfNoSQLite(t)
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("XDG_DATA_HOME", "")
+
+	dbPath := zedThreadsDBPath()
+	if dbPath == "" {
+		t.Skip("Zed storage location unknown on this OS")
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	recent := strings.ReplaceAll(testZedThread, "2026-02-10T10:30:00Z", time.Now().UTC().Format(time.RFC3339))
+	stale := strings.ReplaceAll(strings.ReplaceAll(testZedThread, "src/main.rs", "src/old.rs"), "2026-02-10T10:30:00Z", "2020-01-01T00:00:00Z")
+	createTestDB(t, dbPath, []string{
+		`CREATE TABLE threads (id TEXT PRIMARY KEY, summary TEXT NOT NULL, updated_at TEXT NOT NULL, data_type TEXT NOT NULL, data BLOB NOT NULL)`,
+		`INSERT INTO threads VALUES ('a', 'recent', '` + time.Now().UTC().Format(time.RFC3339) + `', 'json', CAST('` + recent + `' AS BLOB))`,
+		`INSERT INTO threads VALUES ('b', 'stale', '2020-01-01T00:00:00Z', 'json', CAST('` + stale + `' AS BLOB))`,
+		`INSERT INTO threads VALUES ('c', 'compressed', '` + time.Now().UTC().Format(time.RFC3339) + `', 'zstd', X'28B52FFD')`,
+	})
+
+	info, err := detectZed(testRepoRoot, 72*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("expected non-nil info")
+	}
+	if info.Tool != ToolZed {
+		t.Errorf("tool: got %q", info.Tool)
+	}
+	if got := sortedKeys(info.FilesWritten); !equal(got, []string{"src/main.rs"}) {
+		t.Errorf("files: got %v", got)
+	}
+}
+
+func TestDetectZed_NoDatabase(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	info, err := detectZed(testRepoRoot, 72*time.Hour)
+	if err != nil || info != nil {
+		t.Errorf("expected nil, nil without Zed, got %+v, %v", info, err)
+	}
+}